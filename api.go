@@ -1,7 +1,11 @@
 package japi
 
 import (
+	"fmt"
 	"net/http"
+	"reflect"
+	"strings"
+	"sync/atomic"
 
 	"github.com/julienschmidt/httprouter"
 
@@ -15,20 +19,33 @@ const JsonEncoding = "application/json"
 type Middleware func(http.Handler) http.Handler
 
 type Router interface {
-	Get(path string, handle http.Handler)
-	Post(path string, handle http.Handler)
-	Put(path string, handle http.Handler)
-	Delete(path string, handle http.Handler)
-	Handle(method, path string, handle http.Handler)
-	HandleFunc(method, path string, handle http.HandlerFunc)
-	Group(path string) Router
+	Get(path string, handle http.Handler, opts ...RouteOption)
+	Post(path string, handle http.Handler, opts ...RouteOption)
+	Put(path string, handle http.Handler, opts ...RouteOption)
+	Patch(path string, handle http.Handler, opts ...RouteOption)
+	Delete(path string, handle http.Handler, opts ...RouteOption)
+	Handle(method, path string, handle http.Handler, opts ...RouteOption)
+	HandleFunc(method, path string, handle http.HandlerFunc, opts ...RouteOption)
+	Group(path string, opts ...RouteOption) Router
+	Mount(path string, child *API)
+	Version(version string) Router
 	Use(mw ...Middleware)
 }
 
 type API struct {
 	router *httprouter.Router
-	config *Config
+	config *configRef
 	mw     []Middleware
+	routes []*Route
+
+	registered []registration
+
+	versions map[string]*versionedRoute
+	hosts    map[string]*API
+	children []*API
+	inFlight atomic.Pointer[chan struct{}]
+	crons    []*cronJob
+	tracker  *tracker
 
 	NotFound         http.Handler
 	MethodNotAllowed http.Handler
@@ -45,15 +62,43 @@ func New(c *Config) *API {
 	r.RedirectTrailingSlash = true
 	r.SaveMatchedRoutePath = true
 
-	return &API{
+	ref := newConfigRef(c)
+	a := &API{
 		router:           r,
-		config:           c,
-		NotFound:         withConfig(E(problem.NotFound()), c),
-		MethodNotAllowed: withConfig(E(problem.Status(http.StatusMethodNotAllowed)), c),
+		config:           ref,
+		tracker:          newTracker(),
+		NotFound:         withConfig(E(problem.NotFound()), ref),
+		MethodNotAllowed: withConfig(E(problem.Status(http.StatusMethodNotAllowed)), ref),
 		PanicHandler: func(w http.ResponseWriter, r *http.Request, err any) {
-			withConfig(E(problem.Status(http.StatusInternalServerError)), c).ServeHTTP(w, r)
+			withConfig(E(problem.Status(http.StatusInternalServerError)), ref).ServeHTTP(w, r)
 		},
 	}
+	a.setInFlight(c.MaxInFlight)
+
+	return a
+}
+
+// Config returns the current config. Use UpdateConfig to change it.
+func (r *API) Config() Config {
+	return *r.config.Load()
+}
+
+// UpdateConfig swaps the config for a copy changed by the func. Requests in
+// flight finish with the config they started with. The Tracer and Metrics
+// are only read when the routes are registered.
+func (r *API) UpdateConfig(fn func(c *Config)) {
+	old, c := r.config.update(fn)
+	if c.MaxInFlight != old.MaxInFlight {
+		r.setInFlight(c.MaxInFlight)
+	}
+}
+
+func (r *API) setInFlight(n int) {
+	var sem chan struct{}
+	if n > 0 {
+		sem = make(chan struct{}, n)
+	}
+	r.inFlight.Store(&sem)
 }
 
 // Router creates a http.Handler for the API.
@@ -68,54 +113,163 @@ func (r *API) Router() http.Handler {
 	for i := len(r.mw) - 1; i >= 0; i-- {
 		h = r.mw[i](h)
 	}
+	h = withTenant(r.config, h)
+	h = withLocales(h)
+	h = withClientIP(r.config, h)
+	if len(r.hosts) > 0 {
+		h = r.hostHandler(h)
+	}
 	return h
 }
 
 // Get handles GET requests.
-func (r *API) Get(path string, handle http.Handler) {
-	r.Handle(http.MethodGet, path, handle)
+func (r *API) Get(path string, handle http.Handler, opts ...RouteOption) {
+	r.Handle(http.MethodGet, path, handle, opts...)
 }
 
 // Post handles POST requests.
-func (r *API) Post(path string, handle http.Handler) {
-	r.Handle(http.MethodPost, path, handle)
+func (r *API) Post(path string, handle http.Handler, opts ...RouteOption) {
+	r.Handle(http.MethodPost, path, handle, opts...)
 }
 
 // Put handles PUT requests.
-func (r *API) Put(path string, handle http.Handler) {
-	r.Handle(http.MethodPut, path, handle)
+func (r *API) Put(path string, handle http.Handler, opts ...RouteOption) {
+	r.Handle(http.MethodPut, path, handle, opts...)
 }
 
 // Patch handles PATCH requests.
-func (r *API) Patch(path string, handle http.Handler) {
-	r.Handle(http.MethodPatch, path, handle)
+func (r *API) Patch(path string, handle http.Handler, opts ...RouteOption) {
+	r.Handle(http.MethodPatch, path, handle, opts...)
 }
 
 // Delete handles DELETE requests.
-func (r *API) Delete(path string, handle http.Handler) {
-	r.Handle(http.MethodDelete, path, handle)
+func (r *API) Delete(path string, handle http.Handler, opts ...RouteOption) {
+	r.Handle(http.MethodDelete, path, handle, opts...)
 }
 
 // Handle can be used to wrap regular handlers.
-func (r *API) Handle(method, path string, handle http.Handler) {
+func (r *API) Handle(method, path string, handle http.Handler, opts ...RouteOption) {
+	rt := newRoute(method, path, opts)
+	r.register(rt.Method, rt.Path, r.wrap(rt, handle))
+	r.routes = append(r.routes, rt)
+}
+
+// wrap binds the handler to the route with the configured timeout, tracing
+// and metrics.
+func (r *API) wrap(rt *Route, handle http.Handler) httprouter.Handle {
 	var hh httprouter.Handle
 	if h, ok := handle.(Handler); ok {
-		hh = withConfig(h, r.config).handle
+		if t, ok := h.(interface {
+			types() (reflect.Type, reflect.Type)
+		}); ok {
+			rt.request, rt.response = t.types()
+		}
+		hh = withRoute(withConfig(h, r.config), r, rt).handle
 	} else {
+		if h, ok := handle.(ProblemsSetter); ok {
+			h.SetProblems(Problems{config: r.config})
+		}
 		hh = wrapHandler(handle)
 	}
 
-	r.router.Handle(method, path, hh)
+	if len(rt.params) > 0 {
+		hh = withParams(r.config, rt.params, hh)
+	}
+
+	hh = timeout(r.config, rt.timeout, hh)
+
+	if rt.idempotency != nil {
+		hh = idempotent(r.config, rt, rt.idempotency, hh)
+	}
+
+	if rt.coalescer != nil {
+		hh = coalesce(r.config, rt.coalescer, hh)
+	}
+
+	if rt.signature != nil {
+		hh = verify(r.config, rt, rt.signature, hh)
+	}
+
+	if rt.deprecation != nil {
+		hh = deprecated(r.config, rt.Path, rt.deprecation, hh)
+	}
+
+	if len(rt.hints) > 0 {
+		hh = earlyHints(rt.hints, hh)
+	}
+
+	if rt.breaker != nil {
+		hh = protect(r.config, rt.breaker, hh)
+	}
+
+	if rt.concurrency > 0 {
+		hh = limit(r.config, make(chan struct{}, rt.concurrency), hh)
+	}
+
+	hh = r.track(rt.Method+" "+rt.Path, hh)
+
+	c := r.config.Load()
+	if c.Tracer != nil {
+		hh = trace(c.Tracer, rt.Path, hh)
+	}
+
+	if c.Metrics != nil {
+		hh = instrument(c.Metrics, rt.Path, hh)
+	}
+
+	return hh
+}
+
+// Routes returns the registered routes.
+func (r *API) Routes() []*Route {
+	return append([]*Route(nil), r.routes...)
+}
+
+// URL builds the path for the named route using the param key value pairs.
+func (r *API) URL(name string, params ...string) (string, error) {
+	for _, rt := range r.routes {
+		if rt.Name == name {
+			return rt.URL(params...)
+		}
+	}
+	return "", fmt.Errorf("japi: route %q not found", name)
 }
 
 // HandleFunc handles the requests with the specified method.
-func (r *API) HandleFunc(method, path string, handle http.HandlerFunc) {
-	r.Handle(method, path, handle)
+func (r *API) HandleFunc(method, path string, handle http.HandlerFunc, opts ...RouteOption) {
+	r.Handle(method, path, handle, opts...)
 }
 
-// Group creates a new sub-router with the given prefix.
-func (r *API) Group(path string) Router {
-	return &group{prefix: path, r: r}
+// Group creates a new sub-router with the given prefix. The options apply to
+// every route of the group before the options of the route.
+func (r *API) Group(path string, opts ...RouteOption) Router {
+	return &group{prefix: path, r: r, opts: opts}
+}
+
+// Mount serves the child API under the path prefix. The child keeps its own
+// config and middleware and its cron funcs and requests in flight are run and
+// drained by Serve. Routes registered on the child after it is mounted are
+// served but not included in Routes. It panics when the prefix conflicts with
+// the routes of the API e.g. a root mount with any other route.
+func (r *API) Mount(path string, child *API) {
+	path = strings.TrimSuffix(path, "/")
+	h := http.StripPrefix(path, child.Router())
+
+	for _, method := range mountMethods {
+		r.registerMount(method, path, wrapHandler(h))
+	}
+	r.children = append(r.children, child)
+
+	for _, rt := range child.routes {
+		mounted := *rt
+		mounted.Path = path + rt.Path
+		r.routes = append(r.routes, &mounted)
+	}
+}
+
+var mountMethods = []string{
+	http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut,
+	http.MethodPatch, http.MethodDelete, http.MethodOptions,
 }
 
 // Use will register middleware to run prior to the handlers.
@@ -123,14 +277,23 @@ func (r *API) Use(mw ...Middleware) {
 	r.mw = append(r.mw, mw...)
 }
 
-func withConfig(handle Handler, c *Config) Handler {
-	if h, ok := handle.(interface{ setConfig(*Config) }); ok {
+func withConfig(handle Handler, c *configRef) Handler {
+	if h, ok := handle.(interface{ setConfig(*configRef) }); ok {
 		h.setConfig(c)
 	}
 
 	return handle
 }
 
+// withRoute binds the handler to the registered route.
+func withRoute(handle Handler, a *API, rt *Route) Handler {
+	if h, ok := handle.(interface{ withRoute(*API, *Route) Handler }); ok {
+		return h.withRoute(a, rt)
+	}
+
+	return handle
+}
+
 func wrapHandler(h http.Handler) httprouter.Handle {
 	return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
 		h.ServeHTTP(w, r)
@@ -0,0 +1,256 @@
+package japi
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/jarrettv/go-japi/problem"
+)
+
+// The statuses of a job.
+const (
+	JobPending   = "pending"
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed"
+)
+
+// Job is the status of an async request.
+type Job struct {
+	ID       string           `json:"id"`
+	Status   string           `json:"status"`
+	Progress float64          `json:"progress,omitempty"`
+	Result   any              `json:"result,omitempty"`
+	Problem  *problem.Problem `json:"problem,omitempty"`
+	Created  time.Time        `json:"created"`
+	Updated  time.Time        `json:"updated"`
+}
+
+// JobStore stores the status of the jobs.
+type JobStore interface {
+	Save(ctx context.Context, job Job) error
+	Get(ctx context.Context, id string) (Job, bool, error)
+}
+
+// Jobs runs async requests in a pool of workers.
+type Jobs struct {
+	store  JobStore
+	queue  chan func()
+	wg     sync.WaitGroup
+	config *configRef
+	path   string
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+// NewJobs creates the jobs and starts the workers. Requests respond with a 503
+// problem when the queue is full.
+func NewJobs(store JobStore, workers, queue int) *Jobs {
+	j := &Jobs{store: store, queue: make(chan func(), queue)}
+	for i := 0; i < workers; i++ {
+		j.wg.Add(1)
+		go func() {
+			defer j.wg.Done()
+			for run := range j.queue {
+				run()
+			}
+		}()
+	}
+	return j
+}
+
+// Close stops accepting jobs and waits for the queued jobs to finish. Requests
+// respond with a 503 problem after it is closed.
+func (j *Jobs) Close() {
+	j.mu.Lock()
+	if !j.closed {
+		j.closed = true
+		close(j.queue)
+	}
+	j.mu.Unlock()
+	j.wg.Wait()
+}
+
+// enqueue queues the run unless the jobs are closed or the queue is full.
+func (j *Jobs) enqueue(run func()) bool {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+
+	if j.closed {
+		return false
+	}
+	select {
+	case j.queue <- run:
+		return true
+	default:
+		return false
+	}
+}
+
+// Jobs registers the status endpoint of the jobs at path/:id.
+func (r *API) Jobs(path string, jobs *Jobs) {
+	jobs.config = r.config
+	jobs.path = path
+	r.Get(path+"/:id", H(func(ctx context.Context, req struct {
+		ID string `path:"id"`
+	}) (*Job, error) {
+		job, ok, err := jobs.store.Get(ctx, req.ID)
+		if err != nil {
+			return nil, err
+		} else if !ok {
+			return nil, problem.NotFound()
+		}
+		return &job, nil
+	}))
+}
+
+// JobAccepted is the response of an async request.
+type JobAccepted struct {
+	ID        string `json:"id"`
+	Status    string `json:"status"`
+	StatusURL string `json:"statusUrl"`
+}
+
+func (a *JobAccepted) StatusCode() int  { return http.StatusAccepted }
+func (a *JobAccepted) Location() string { return a.StatusURL }
+
+// Async responds with 202 Accepted and the status URL then runs the handler
+// with the jobs. Register the status endpoint with API.Jobs.
+func Async[T any, O any](handle Handle[T, O], jobs *Jobs) Handler {
+	return H(func(ctx context.Context, req T) (*JobAccepted, error) {
+		if jobs.config == nil {
+			return nil, errors.New("japi: jobs status endpoint is not registered")
+		}
+
+		now := time.Now()
+		job := Job{ID: newJobID(), Status: JobPending, Created: now, Updated: now}
+		if err := jobs.store.Save(ctx, job); err != nil {
+			return nil, err
+		}
+
+		ctx = context.WithoutCancel(ctx)
+		run := func() { jobs.run(ctx, job, func(ctx context.Context) (any, error) { return handle(ctx, req) }) }
+
+		if !jobs.enqueue(run) {
+			// the job is failed so it does not stay pending
+			job.Status, job.Problem, job.Updated = JobFailed, problem.Unavailable(), time.Now()
+			_ = jobs.store.Save(ctx, job)
+			return nil, problem.Unavailable()
+		}
+
+		return &JobAccepted{ID: job.ID, Status: job.Status, StatusURL: jobs.path + "/" + job.ID}, nil
+	})
+}
+
+func (j *Jobs) run(ctx context.Context, job Job, handle func(context.Context) (any, error)) {
+	job.Status, job.Updated = JobRunning, time.Now()
+	_ = j.store.Save(ctx, job)
+
+	res, err := func() (res any, err error) {
+		defer func() {
+			if v := recover(); v != nil {
+				err = fmt.Errorf("japi: job panic: %v", v)
+			}
+		}()
+		return handle(context.WithValue(ctx, jobKey{}, &jobRun{jobs: j, job: &job}))
+	}()
+
+	job.Updated = time.Now()
+	if err != nil {
+		p, ok := asProblem(err)
+		if !ok {
+			p = problem.Unexpected(err)
+		}
+		j.config.Load().prepareProblem(ctx, p)
+		job.Status, job.Problem = JobFailed, p
+	} else {
+		job.Status, job.Progress, job.Result = JobSucceeded, 1, res
+	}
+	_ = j.store.Save(ctx, job)
+}
+
+type jobKey struct{}
+
+type jobRun struct {
+	jobs *Jobs
+	job  *Job
+}
+
+// JobProgress reports the progress between 0 and 1 of the async request.
+func JobProgress(ctx context.Context, progress float64) error {
+	run, ok := ctx.Value(jobKey{}).(*jobRun)
+	if !ok {
+		return nil
+	}
+
+	run.job.Progress, run.job.Updated = progress, time.Now()
+	return run.jobs.store.Save(ctx, *run.job)
+}
+
+func newJobID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// MemoryJobStore is an in memory JobStore for a single instance.
+type MemoryJobStore struct {
+	ttl     time.Duration
+	mu      sync.Mutex
+	jobs    map[string]Job
+	expires []expiringJob
+}
+
+// expiringJob is when a finished job expires. The jobs finish in order so the
+// expiring jobs are ordered by their expiry.
+type expiringJob struct {
+	id string
+	at time.Time
+}
+
+// NewMemoryJobStore creates an in memory JobStore that keeps the finished
+// jobs for the ttl.
+func NewMemoryJobStore(ttl time.Duration) *MemoryJobStore {
+	return &MemoryJobStore{ttl: ttl, jobs: map[string]Job{}}
+}
+
+// Save saves the job and removes the expired jobs.
+func (s *MemoryJobStore) Save(_ context.Context, job Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for len(s.expires) > 0 && now.After(s.expires[0].at) {
+		id := s.expires[0].id
+		s.expires = s.expires[1:]
+		if j, ok := s.jobs[id]; ok && finished(j) && now.Sub(j.Updated) > s.ttl {
+			delete(s.jobs, id)
+		}
+	}
+
+	s.jobs[job.ID] = job
+	if finished(job) {
+		s.expires = append(s.expires, expiringJob{id: job.ID, at: job.Updated.Add(s.ttl)})
+	}
+	return nil
+}
+
+func finished(job Job) bool {
+	return job.Status == JobSucceeded || job.Status == JobFailed
+}
+
+// Get returns the job.
+func (s *MemoryJobStore) Get(_ context.Context, id string) (Job, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[id]
+	return job, ok, nil
+}
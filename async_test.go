@@ -0,0 +1,48 @@
+package japi
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAsyncUnavailable(t *testing.T) {
+	tests := []struct {
+		name    string
+		workers int
+		queue   int
+		close   bool
+	}{
+		{"queue full", 0, 0, false},
+		{"closed", 1, 1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewMemoryJobStore(time.Minute)
+			jobs := NewJobs(store, tt.workers, tt.queue)
+			r := New(nil)
+			r.UpdateConfig(func(c *Config) { c.Logger = nil })
+			r.Jobs("/jobs", jobs)
+			r.Post("/reports", Async(func(context.Context, Empty) (*Empty, error) {
+				return &Empty{}, nil
+			}, jobs))
+			if tt.close {
+				jobs.Close()
+			}
+
+			w := httptest.NewRecorder()
+			r.Router().ServeHTTP(w, httptest.NewRequest("POST", "/reports", strings.NewReader("{}")))
+			if w.Code != 503 {
+				t.Fatalf("status %d: %s", w.Code, w.Body)
+			}
+			for _, job := range store.jobs {
+				if job.Status != JobFailed {
+					t.Errorf("job %s is %s", job.ID, job.Status)
+				}
+			}
+		})
+	}
+}
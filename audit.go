@@ -0,0 +1,200 @@
+package japi
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const redactTag = "redact"
+
+// AuditRecord is the audit of a handled request.
+type AuditRecord struct {
+	Time     time.Time
+	Method   string
+	Route    string
+	Version  string
+	User     string
+	IP       string
+	Tenant   string
+	Request  any // the decoded request with the redacted fields cleared, nil when decoding failed
+	Status   int
+	Duration time.Duration
+}
+
+// AuditSink receives the audit records.
+type AuditSink func(rec AuditRecord)
+
+// Auditor delivers the audit records of the requests to the sink asynchronously.
+// Records are dropped when the buffer is full.
+type Auditor struct {
+	// User returns the identity of the user from the request context.
+	User func(ctx context.Context) string
+
+	sink    AuditSink
+	records chan AuditRecord
+	done    chan struct{}
+	mu      sync.RWMutex
+	closed  bool
+	dropped atomic.Int64
+}
+
+// NewAuditor creates the auditor and starts delivering records to the sink.
+func NewAuditor(sink AuditSink, buffer int) *Auditor {
+	a := &Auditor{
+		sink:    sink,
+		records: make(chan AuditRecord, buffer),
+		done:    make(chan struct{}),
+	}
+
+	go func() {
+		defer close(a.done)
+		for rec := range a.records {
+			a.sink(rec)
+		}
+	}()
+
+	return a
+}
+
+// Dropped returns the number of records dropped because the buffer was full.
+func (a *Auditor) Dropped() int64 {
+	return a.dropped.Load()
+}
+
+// Close stops accepting records and waits for the buffered records to be delivered.
+func (a *Auditor) Close() {
+	a.mu.Lock()
+	if !a.closed {
+		a.closed = true
+		close(a.records)
+	}
+	a.mu.Unlock()
+	<-a.done
+}
+
+func (a *Auditor) record(rec AuditRecord) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.closed {
+		return
+	}
+
+	select {
+	case a.records <- rec:
+	default:
+		a.dropped.Add(1)
+	}
+}
+
+// audit records the request when the writer is done.
+func (a *Auditor) audit(r *http.Request, route string, start time.Time, sw *statusWriter, req any) {
+	rec := AuditRecord{
+		Time:     start,
+		Method:   r.Method,
+		Route:    route,
+		Version:  APIVersion(r.Context()),
+		IP:       ClientIP(r.Context()),
+		Tenant:   Tenant(r.Context()),
+		Request:  req,
+		Status:   sw.Status(),
+		Duration: time.Since(start),
+	}
+	if a.User != nil {
+		rec.User = a.User(r.Context())
+	}
+	a.record(rec)
+}
+
+// hasRedactTag reports whether the type has redact tagged fields, also in the
+// structs of its slices, arrays and maps.
+func hasRedactTag(t reflect.Type) bool {
+	return redactable(t, map[reflect.Type]bool{})
+}
+
+func redactable(t reflect.Type, seen map[reflect.Type]bool) bool {
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Array ||
+		t.Kind() == reflect.Map {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || seen[t] {
+		return false
+	}
+	seen[t] = true
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue // skip unexported fields
+		}
+		if _, ok := f.Tag.Lookup(redactTag); ok || redactable(f.Type, seen) {
+			return true
+		}
+	}
+	return false
+}
+
+// redact clears the fields tagged with redact:"true" in the value. The
+// pointers, slices and maps on the way are copied so the request of the
+// handler is not changed.
+func redact(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return
+		}
+		cp := reflect.New(v.Type().Elem())
+		cp.Elem().Set(v.Elem())
+		redact(cp.Elem())
+		v.Set(cp)
+	case reflect.Slice:
+		if v.IsNil() {
+			return
+		}
+		cp := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		reflect.Copy(cp, v)
+		for i := 0; i < cp.Len(); i++ {
+			redact(cp.Index(i))
+		}
+		v.Set(cp)
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			redact(v.Index(i))
+		}
+	case reflect.Map:
+		if v.IsNil() {
+			return
+		}
+		cp := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			e := reflect.New(v.Type().Elem()).Elem()
+			e.Set(iter.Value())
+			redact(e)
+			cp.SetMapIndex(iter.Key(), e)
+		}
+		v.Set(cp)
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if f.PkgPath != "" {
+				continue // skip unexported fields
+			}
+
+			fv := v.Field(i)
+			if f.Tag.Get(redactTag) == "true" {
+				if fv.Kind() == reflect.String {
+					fv.SetString("[REDACTED]")
+				} else {
+					fv.Set(reflect.Zero(f.Type))
+				}
+			} else if hasRedactTag(f.Type) {
+				redact(fv)
+			}
+		}
+	}
+}
@@ -0,0 +1,236 @@
+package japi
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+
+	"github.com/goccy/go-json"
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/jarrettv/go-japi/problem"
+)
+
+// NDJSONEncoding is the media type of newline delimited json.
+const NDJSONEncoding = "application/x-ndjson"
+
+// MaxBatchLine is the max size in bytes of a batch request line.
+var MaxBatchLine = 1 << 20
+
+// BatchResult is the response line for an item of the batch.
+type BatchResult struct {
+	Index   int              `json:"index"`
+	Result  any              `json:"result,omitempty"`
+	Problem *problem.Problem `json:"problem,omitempty"`
+}
+
+// BatchOption configures the batch handler.
+type BatchOption func(*batchOptions)
+
+type batchOptions struct {
+	concurrency int
+}
+
+// BatchConcurrency handles up to n items of the batch at once. The results are
+// still written in the order of the items.
+func BatchConcurrency(n int) BatchOption {
+	return func(o *batchOptions) {
+		o.concurrency = n
+	}
+}
+
+// Batch wraps your handler to handle each line of a newline delimited json
+// request and stream back a BatchResult line for each item.
+func Batch[T any, O any](handle Handle[T, O], opts ...BatchOption) Handler {
+	h := &batchHandler[T, O]{handler: handle, batchOptions: batchOptions{concurrency: 1}}
+	h.validator = newValidator(reflect.TypeOf((*T)(nil)).Elem())
+	for _, opt := range opts {
+		opt(&h.batchOptions)
+	}
+	if h.concurrency < 1 {
+		h.concurrency = 1
+	}
+	return h
+}
+
+type batchHandler[T any, O any] struct {
+	batchOptions
+	config    *configRef
+	route     *Route
+	handler   Handle[T, O]
+	validator *structValidator
+}
+
+func (h *batchHandler[T, O]) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.handle(w, r, nil)
+}
+
+func (h *batchHandler[T, O]) handle(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	config := h.config.Load().forTenant(r.Context())
+
+	if mt, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mt != NDJSONEncoding {
+		config.serveProblem(w, r, problem.Status(http.StatusUnsupportedMediaType))
+		return
+	}
+	if !limitBody(w, r, h.route.maxBodyBytes(config)) {
+		config.serveProblem(w, r, problem.Status(http.StatusRequestEntityTooLarge))
+		return
+	}
+
+	// HTTP/1.x closes the body when the response is first flushed unless the
+	// connection is full duplex so the body is read first without it
+	duplex := http.NewResponseController(w).EnableFullDuplex() == nil
+	var lines [][]byte
+	var scanErr error
+	if !duplex {
+		scanErr = scanBatch(r.Body, func(line []byte) {
+			lines = append(lines, line)
+		})
+	}
+
+	w.Header().Set("Content-Type", NDJSONEncoding)
+	w.WriteHeader(http.StatusOK)
+	flusher, _ := w.(http.Flusher)
+
+	// the items are queued in order so the results are written in order
+	queue := make(chan chan BatchResult, h.concurrency)
+	sem := make(chan struct{}, h.concurrency)
+
+	go func() {
+		defer close(queue)
+
+		index := 0
+		dispatch := func(line []byte) {
+			res := make(chan BatchResult, 1)
+			queue <- res
+			sem <- struct{}{}
+			go func(index int) {
+				defer func() { <-sem }()
+				res <- h.item(r, config, index, line)
+			}(index)
+			index++
+		}
+
+		if duplex {
+			scanErr = scanBatch(r.Body, dispatch)
+			return
+		}
+		for _, line := range lines {
+			dispatch(line)
+		}
+	}()
+
+	for res := range queue {
+		writeBatchResult(w, <-res)
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+
+	if scanErr != nil {
+		p := problem.BadRequest(scanErr)
+		if isTooLarge(scanErr) {
+			p = problem.Status(http.StatusRequestEntityTooLarge)
+		}
+		config.prepareProblem(withRequest(r), p)
+		writeBatchResult(w, BatchResult{Index: -1, Problem: p})
+	}
+}
+
+// scanBatch calls the func with a copy of each line of the body that is not
+// empty. A line cut off by an error reading the body is not a line.
+func scanBatch(r io.Reader, fn func(line []byte)) error {
+	body := &bodyReader{Reader: r}
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), MaxBatchLine)
+	scanner.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+		if atEOF && body.err != nil && len(data) > 0 && bytes.IndexByte(data, '\n') < 0 {
+			return 0, nil, body.err
+		}
+		return bufio.ScanLines(data, atEOF)
+	})
+	for scanner.Scan() {
+		if len(scanner.Bytes()) > 0 {
+			fn(append([]byte(nil), scanner.Bytes()...))
+		}
+	}
+	return scanner.Err()
+}
+
+// item decodes and handles the line of the batch like H decodes a json body.
+// A panic is the unexpected problem of the item.
+func (h *batchHandler[T, O]) item(r *http.Request, config *Config, index int, line []byte) (result BatchResult) {
+	ctx := r.Context()
+	result = BatchResult{Index: index}
+	defer func() {
+		if v := recover(); v != nil {
+			result = BatchResult{Index: index, Problem: problem.Unexpected(fmt.Errorf("japi: batch panic: %v", v))}
+			config.prepareProblem(withRequest(r), result.Problem)
+		}
+	}()
+
+	req := new(T)
+	errs := problem.Errors{}
+	e := error(nil)
+	if h.route.disallowUnknownFields(config) {
+		e = addUnknownFields(bytes.NewReader(line), reflect.TypeOf(req).Elem(), errs)
+	}
+	if e == nil && len(errs) == 0 {
+		e = unmarshalWith(config.JSONUnmarshal, line, decodeTarget(req))
+	}
+	if e != nil {
+		addDecodeError(errs, bodyField, e)
+	}
+
+	if len(errs) > 0 {
+		result.Problem = problem.ValidationErrors(errs)
+	} else if e := runAfterDecode(config, r, decodeTarget(req)); e != nil {
+		result.Problem = batchProblem(e, problem.BadRequest)
+	} else if errs := validateRequest(config, h.validator, decodeTarget(req)); len(errs) > 0 {
+		result.Problem = problem.ValidationErrors(errs)
+	} else if res, e := h.handler(ctx, *req); e != nil {
+		result.Problem = batchProblem(e, problem.Unexpected)
+	} else {
+		result.Result = res
+	}
+
+	if result.Problem != nil {
+		config.prepareProblem(withRequest(r), result.Problem)
+	}
+	return result
+}
+
+func batchProblem(e error, fallback func(error) *problem.Problem) *problem.Problem {
+	if p, ok := asProblem(e); ok {
+		return p
+	}
+	if errors.Is(e, context.DeadlineExceeded) {
+		return problem.Timeout()
+	}
+	return fallback(e)
+}
+
+func writeBatchResult(w http.ResponseWriter, res BatchResult) {
+	data, err := json.Marshal(res)
+	if err != nil {
+		p := problem.Unexpected(err)
+		data, _ = json.Marshal(BatchResult{Index: res.Index, Problem: p})
+	}
+	_, _ = w.Write(append(data, '\n'))
+}
+
+func (h *batchHandler[T, O]) setConfig(c *configRef) {
+	h.config = c
+}
+
+func (h *batchHandler[T, O]) withRoute(_ *API, rt *Route) Handler {
+	hc := *h
+	hc.route = rt
+	return &hc
+}
@@ -0,0 +1,81 @@
+package japi
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/goccy/go-json"
+)
+
+type batchItem struct {
+	N int `json:"n"`
+}
+
+func TestBatchStreamsOverServer(t *testing.T) {
+	r := New(nil)
+	r.UpdateConfig(func(c *Config) { c.Logger = nil })
+	r.Post("/batch", Batch(func(_ context.Context, req batchItem) (*batchItem, error) {
+		return &batchItem{N: req.N * 2}, nil
+	}, BatchConcurrency(4)))
+	srv := httptest.NewServer(r.Router())
+	defer srv.Close()
+
+	const n = 200
+	pr, pw := io.Pipe()
+	go func() {
+		for i := 0; i < n; i++ {
+			fmt.Fprintf(pw, "{\"n\":%d}\n", i)
+		}
+		pw.Close()
+	}()
+
+	res, err := http.Post(srv.URL+"/batch", NDJSONEncoding, pr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	scanner := bufio.NewScanner(res.Body)
+	i := 0
+	for ; scanner.Scan(); i++ {
+		var got struct {
+			Index   int             `json:"index"`
+			Result  batchItem       `json:"result"`
+			Problem json.RawMessage `json:"problem"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &got); err != nil {
+			t.Fatal(err)
+		}
+		if got.Index != i || got.Result.N != i*2 || got.Problem != nil {
+			t.Fatalf("line %d: %s", i, scanner.Bytes())
+		}
+	}
+	if i != n {
+		t.Errorf("got %d results, want %d", i, n)
+	}
+}
+
+func TestBatchReadsBodyWithoutFullDuplex(t *testing.T) {
+	r := New(nil)
+	r.UpdateConfig(func(c *Config) { c.Logger = nil })
+	r.Post("/batch", Batch(func(_ context.Context, req batchItem) (*batchItem, error) {
+		return &req, nil
+	}))
+
+	// the recorder does not support full duplex
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("POST", "/batch", strings.NewReader("{\"n\":1}\n\n{\"n\":2}\n{\"n\":"))
+	req.Header.Set("Content-Type", NDJSONEncoding)
+	r.Router().ServeHTTP(w, req)
+
+	want := `{"index":0,"result":{"n":1}}` + "\n" + `{"index":1,"result":{"n":2}}` + "\n"
+	if got := w.Body.String(); !strings.HasPrefix(got, want) || !strings.Contains(got, `"index":2,"problem"`) {
+		t.Errorf("got %s", got)
+	}
+}
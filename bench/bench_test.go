@@ -0,0 +1,103 @@
+// Package bench compares the overhead of japi handlers with net/http and gin.
+// It is a separate module so gin is not a dependency of japi.
+//
+//	go test -bench . -benchmem
+package bench
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/jarrettv/go-japi"
+)
+
+type greetRequest struct {
+	ID   string `path:"id" json:"-" uri:"id"`
+	Name string `json:"name"`
+	Age  int    `json:"age"`
+}
+
+type greetResponse struct {
+	ID       string `json:"id"`
+	Greeting string `json:"greeting"`
+}
+
+const body = `{"name":"Ada","age":36}`
+
+func greet(_ context.Context, req greetRequest) (*greetResponse, error) {
+	return &greetResponse{ID: req.ID, Greeting: "Hello " + req.Name}, nil
+}
+
+func newJapi(reuse bool) http.Handler {
+	r := japi.New(nil)
+	r.UpdateConfig(func(c *japi.Config) {
+		c.Logger = nil
+		c.ReuseRequests = reuse
+	})
+	r.Post("/users/:id/greet", japi.H(greet))
+	return r.Router()
+}
+
+func newNetHTTP() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /users/{id}/greet", func(w http.ResponseWriter, r *http.Request) {
+		req := greetRequest{ID: r.PathValue("id")}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		res, _ := greet(r.Context(), req)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(res)
+	})
+	return mux
+}
+
+func newGin() http.Handler {
+	gin.SetMode(gin.ReleaseMode)
+	g := gin.New()
+	g.POST("/users/:id/greet", func(c *gin.Context) {
+		var req greetRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		req.ID = c.Param("id")
+		res, _ := greet(c.Request.Context(), req)
+		c.JSON(http.StatusOK, res)
+	})
+	return g
+}
+
+func BenchmarkGreet(b *testing.B) {
+	handlers := []struct {
+		name string
+		h    http.Handler
+	}{
+		{"net/http", newNetHTTP()},
+		{"gin", newGin()},
+		{"japi", newJapi(false)},
+		{"japi/ReuseRequests", newJapi(true)},
+	}
+
+	for _, tt := range handlers {
+		b.Run(tt.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				r := httptest.NewRequest("POST", "/users/7/greet", strings.NewReader(body))
+				r.Header.Set("Content-Type", "application/json")
+				w := httptest.NewRecorder()
+				tt.h.ServeHTTP(w, r)
+				if w.Code != http.StatusOK {
+					b.Fatalf("status %d: %s", w.Code, w.Body)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package japi
+
+import (
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/jarrettv/go-japi/problem"
+)
+
+// decodeBody decodes the body while the route validator and the unknown fields
+// of json bodies check a copy streamed to them through pipes. The body is read
+// once without being buffered and reading past the body limit aborts the
+// decoding and the checks together. The decode errors are dropped when the
+// validator rejects the body as the body is not expected to decode then.
+func (h *handler[T, O]) decodeBody(r *http.Request, config *Config, dec RequestParser, req *T, errs problem.Errors) error {
+	var checks []func(body io.Reader) error
+	validatorErrs, unknownErrs := problem.Errors{}, problem.Errors{}
+
+	if isJSONBody(r) {
+		if h.route != nil && h.route.validator != nil {
+			checks = append(checks, func(body io.Reader) error {
+				return h.route.validator.ValidateBody(body, validatorErrs)
+			})
+		}
+		if h.disallowUnknownFields(config) {
+			t := reflect.TypeOf(req).Elem()
+			checks = append(checks, func(body io.Reader) error {
+				return addUnknownFields(body, t, unknownErrs)
+			})
+		}
+	}
+
+	e := teeBody(r, checks, func() error { return dec(r, req) })
+
+	for field, msgs := range validatorErrs {
+		errs[field] = append(errs[field], msgs...)
+	}
+	for field, msgs := range unknownErrs {
+		errs[field] = append(errs[field], msgs...)
+	}
+	if len(validatorErrs) > 0 && !isTooLarge(e) {
+		if _, ok := asProblem(e); !ok {
+			e = nil
+		}
+	}
+	return e
+}
+
+// teeBody runs the decode while each check reads the body the decode reads.
+// The rest of the body is read for the checks after the decode unless reading
+// it failed. The error is the decode error when it is a problem or a read
+// error and otherwise the first error of the decode or the checks.
+func teeBody(r *http.Request, checks []func(body io.Reader) error, decode func() error) error {
+	if len(checks) == 0 {
+		return decode()
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, len(checks))
+	pipes := make([]*io.PipeWriter, len(checks))
+	writers := make([]io.Writer, len(checks))
+	for i, check := range checks {
+		pr, pw := io.Pipe()
+		pipes[i], writers[i] = pw, pw
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = check(pr)
+			_, _ = io.Copy(io.Discard, pr) // the check may return before the end
+		}()
+	}
+
+	body := &bodyReader{Reader: r.Body}
+	tee := io.TeeReader(body, io.MultiWriter(writers...))
+	orig := r.Body
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{tee, orig}
+
+	e := decode()
+	if body.err == nil {
+		_, _ = io.Copy(io.Discard, tee)
+	}
+	r.Body = orig
+
+	for _, pw := range pipes {
+		_ = pw.CloseWithError(body.err) // a nil error is io.EOF
+	}
+	wg.Wait()
+
+	if body.err != nil {
+		e = body.err
+	}
+	if _, ok := asProblem(e); ok || isTooLarge(e) {
+		return e
+	}
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return e
+}
+
+// isJSONBody reports whether the request body is json or is not typed.
+func isJSONBody(r *http.Request) bool {
+	mt, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return err != nil || mt == JsonEncoding || strings.HasSuffix(mt, "+json")
+}
@@ -0,0 +1,62 @@
+package japi
+
+import (
+	"errors"
+	"io"
+	"net/http"
+)
+
+// WithMaxBodyBytes overrides Config.MaxBodyBytes for the route. A negative max
+// is unlimited.
+func WithMaxBodyBytes(max int64) RouteOption {
+	return func(rt *Route) {
+		rt.maxBody = max
+	}
+}
+
+// maxBodyBytes returns the body limit of the route or the config.
+func (h *handler[T, O]) maxBodyBytes(config *Config) int64 {
+	return h.route.maxBodyBytes(config)
+}
+
+// maxBodyBytes returns the body limit of the route or the config. The route
+// may be nil.
+func (rt *Route) maxBodyBytes(config *Config) int64 {
+	if rt != nil && rt.maxBody != 0 {
+		return rt.maxBody
+	}
+	return config.MaxBodyBytes
+}
+
+// limitBody rejects a body with a larger Content-Length before it is read and
+// aborts the decoding of chunked bodies as soon as they read past the max.
+func limitBody(w http.ResponseWriter, r *http.Request, max int64) bool {
+	if max <= 0 {
+		return true
+	}
+	if r.ContentLength > max {
+		return false
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, max)
+	return true
+}
+
+// isTooLarge reports whether the error is from reading past the body limit.
+func isTooLarge(e error) bool {
+	var tooLarge *http.MaxBytesError
+	return errors.As(e, &tooLarge)
+}
+
+// bodyReader records the error reading the body other than io.EOF.
+type bodyReader struct {
+	io.Reader
+	err error
+}
+
+func (b *bodyReader) Read(p []byte) (int, error) {
+	n, err := b.Reader.Read(p)
+	if err != nil && err != io.EOF {
+		b.err = err
+	}
+	return n, err
+}
@@ -0,0 +1,92 @@
+package japi
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/jarrettv/go-japi/problem"
+)
+
+var circuitOpen = problem.Register("circuit-open", http.StatusServiceUnavailable, "Circuit open").
+	Describe("The route failed too many times in a row and is rejecting requests until it cools down")
+
+// breaker counts the consecutive server errors of a route.
+type breaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// WithCircuitBreaker opens the circuit of the route after threshold consecutive
+// 5xx responses, including timeouts, and fast-fails with a 503 problem and
+// Retry-After during the cooldown so a failing dependency is not hammered. After
+// the cooldown a single request is let through to probe the route, closing the
+// circuit when it succeeds and opening it again when it fails.
+func WithCircuitBreaker(threshold int, cooldown time.Duration) RouteOption {
+	return func(rt *Route) {
+		rt.breaker = &breaker{threshold: threshold, cooldown: cooldown}
+	}
+}
+
+// protect wraps the handle with the circuit breaker.
+func protect(ref *configRef, b *breaker, next httprouter.Handle) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+		probe, wait := b.allow()
+		if wait > 0 {
+			ref.Load().serveProblem(w, r, circuitOpen.New("").WithRetryAfter(wait))
+			return
+		}
+
+		sw := newStatusWriter(w)
+		failed := true
+		defer func() { b.done(probe, failed) }()
+
+		next(sw, r, p)
+		failed = sw.Status() >= http.StatusInternalServerError
+	}
+}
+
+// allow returns whether the request probes the route after the cooldown or how
+// long to wait when the circuit is open.
+func (b *breaker) allow() (bool, time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.openedAt.IsZero() {
+		return false, 0
+	}
+	if wait := b.cooldown - time.Since(b.openedAt); wait > 0 {
+		return false, wait
+	}
+	if b.probing {
+		return false, time.Second
+	}
+	b.probing = true
+	return true, 0
+}
+
+// done records the result of the request.
+func (b *breaker) done(probe, failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if probe {
+		b.probing = false
+	}
+	if !failed {
+		b.failures, b.openedAt = 0, time.Time{}
+		return
+	}
+
+	b.failures++
+	if probe || b.failures >= b.threshold {
+		b.openedAt = time.Now()
+	}
+}
@@ -0,0 +1,88 @@
+package japi
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// LastModifieder sets the Last-Modified header of the response. GET and HEAD
+// requests respond with 304 when it was not modified since If-Modified-Since.
+type LastModifieder interface {
+	LastModified() time.Time
+}
+
+// CacheControl sets the Cache-Control header of the successful responses of
+// the route e.g. public, max-age=60. Problems are not cached.
+func CacheControl(value string) RouteOption {
+	return func(rt *Route) {
+		rt.cacheControl = value
+	}
+}
+
+// NoStore stops clients and proxies from storing the responses of the route.
+func NoStore() RouteOption {
+	return CacheControl("no-store")
+}
+
+// notModified sets the Last-Modified header of the response and responds with
+// 304 when the client has the current version.
+func notModified(w http.ResponseWriter, r *http.Request, res any) bool {
+	lm, ok := res.(LastModifieder)
+	if !ok {
+		return false
+	}
+	modified := lm.LastModified().Truncate(time.Second)
+	if modified.IsZero() {
+		return false
+	}
+	w.Header().Set("Last-Modified", modified.UTC().Format(http.TimeFormat))
+
+	// If-None-Match takes precedence over If-Modified-Since
+	if r.Method != http.MethodGet && r.Method != http.MethodHead || r.Header.Get("If-None-Match") != "" {
+		return false
+	}
+	since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil || modified.After(since) {
+		return false
+	}
+
+	h := w.Header()
+	delete(h, "Content-Type")
+	delete(h, "Content-Length")
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// addVary adds the request headers the response varies on to the Vary header
+// once.
+func addVary(h http.Header, names ...string) {
+	vary := h.Values("Vary")
+	for _, name := range names {
+		if !hasToken(vary, name) {
+			vary = append(vary, name)
+			h.Add("Vary", name)
+		}
+	}
+}
+
+// hasToken reports whether the comma separated header values have the token.
+func hasToken(values []string, token string) bool {
+	for _, v := range values {
+		for _, t := range strings.Split(v, ",") {
+			if t = strings.TrimSpace(t); t == "*" || strings.EqualFold(t, token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// negotiates reports whether the response content type depends on the Accept
+// header.
+func (h *handler[T, O]) negotiates(config *Config) bool {
+	if h.route != nil && h.route.output != nil {
+		return false
+	}
+	return len(config.Outputs) > 0 || len(encoders) > 0
+}
@@ -0,0 +1,47 @@
+package japi
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jarrettv/go-japi/problem"
+)
+
+// ProblemType is a registered problem type served by the catalog endpoint.
+type ProblemType struct {
+	problem.Definition
+	URL string `json:"url,omitempty"`
+}
+
+// Problems registers the catalog of the registered problem types at path and
+// each problem type at path/:type. Point ProblemTypeUrlFormat at the path so
+// the problem types dereference to their description.
+func (r *API) Problems(path string) {
+	problemType := func(d problem.Definition) ProblemType {
+		pt := ProblemType{Definition: d}
+		if format := r.config.Load().ProblemTypeUrlFormat; format != "" {
+			pt.URL = fmt.Sprintf(format, d.Code)
+		}
+		return pt
+	}
+
+	r.Get(path, H(func(ctx context.Context, _ Empty) ([]ProblemType, error) {
+		defs := problem.Catalog()
+		types := make([]ProblemType, len(defs))
+		for i, d := range defs {
+			types[i] = problemType(d)
+		}
+		return types, nil
+	}))
+
+	r.Get(path+"/:type", H(func(ctx context.Context, req struct {
+		Type string `path:"type"`
+	}) (*ProblemType, error) {
+		d, ok := problem.Lookup(req.Type)
+		if !ok {
+			return nil, problem.NotFound()
+		}
+		pt := problemType(d)
+		return &pt, nil
+	}))
+}
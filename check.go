@@ -0,0 +1,178 @@
+package japi
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/jarrettv/go-japi/decoder"
+)
+
+// Validate checks the routes, config and middleware of the API and its hosts
+// so mistakes fail at startup instead of at request time. The errors are
+// joined so all of them are reported at once. Call it after the routes are
+// registered and before serving.
+func (r *API) Validate() error {
+	var errs []error
+	errs = append(errs, r.checkConfig()...)
+	errs = append(errs, r.checkRoutes()...)
+	errs = append(errs, r.checkMiddleware()...)
+
+	hosts := make([]string, 0, len(r.hosts))
+	for host := range r.hosts {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+	for _, host := range hosts {
+		if err := r.hosts[host].Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("japi: host %s:\n%w", host, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (r *API) checkConfig() []error {
+	var errs []error
+	c := r.config.Load()
+
+	if c.ProblemTypeUrlFormat == "" {
+		errs = append(errs, errors.New("japi: ProblemTypeUrlFormat is missing so problem types are not URIs"))
+	} else if s := fmt.Sprintf(c.ProblemTypeUrlFormat, "type"); strings.Contains(s, "%!") {
+		errs = append(errs, fmt.Errorf("japi: ProblemTypeUrlFormat %q must have a single %%s verb for the type",
+			c.ProblemTypeUrlFormat))
+	}
+
+	if t := c.RequestTimeouts; t.Max > 0 && t.Min > t.Max {
+		errs = append(errs, fmt.Errorf("japi: RequestTimeouts Min %s is longer than Max %s", t.Min, t.Max))
+	}
+
+	return errs
+}
+
+func (r *API) checkRoutes() []error {
+	var errs []error
+	names := map[string]*Route{}
+	versions := map[string]bool{}
+
+	for _, rt := range r.routes {
+		if rt.Name != "" {
+			if other, ok := names[rt.Name]; ok && other != rt {
+				errs = append(errs, fmt.Errorf("japi: route %s %s has the name %q of %s %s",
+					rt.Method, rt.Path, rt.Name, other.Method, other.Path))
+			} else {
+				names[rt.Name] = rt
+			}
+		}
+
+		if rt.Version != "" {
+			key := rt.Method + " " + rt.Path + " " + rt.Version
+			if versions[key] {
+				errs = append(errs, fmt.Errorf("japi: route %s %s is registered twice for version %s",
+					rt.Method, rt.Path, rt.Version))
+			}
+			versions[key] = true
+		}
+
+		errs = append(errs, checkRequest(rt)...)
+	}
+
+	return errs
+}
+
+// checkRequest checks the tags of the request type compile and the path tags
+// are params of the route. The handlers skip the tags that do not compile.
+func checkRequest(rt *Route) []error {
+	t := rt.request
+	if t == nil {
+		return nil
+	}
+
+	var errs []error
+	v := reflect.Zero(t).Interface()
+	for _, tag := range []string{headerTag, queryTag, pathTag} {
+		if !hasTag(v, tag) {
+			continue
+		}
+		if _, err := decoder.NewCachedDecoder(v, tag); err != nil {
+			errs = append(errs, fmt.Errorf("japi: route %s %s: %s tags of %s: %w",
+				rt.Method, rt.Path, tag, t, err))
+		}
+	}
+
+	params := map[string]bool{}
+	for _, seg := range strings.Split(rt.Path, "/") {
+		if isWildcard(seg) {
+			params[seg[1:]] = true
+		}
+	}
+	for _, name := range pathNames(t, map[reflect.Type]bool{}) {
+		if !params[name] {
+			errs = append(errs, fmt.Errorf("japi: route %s %s has no :%s param for the path tag of %s",
+				rt.Method, rt.Path, name, t))
+		}
+	}
+
+	return errs
+}
+
+// pathNames returns the path tags of the struct and its untagged nested structs.
+func pathNames(t reflect.Type, seen map[reflect.Type]bool) []string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || seen[t] {
+		return nil
+	}
+	seen[t] = true
+
+	var names []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue // skip unexported fields
+		}
+
+		if tag, ok := f.Tag.Lookup(pathTag); ok {
+			names = append(names, tag)
+			continue
+		}
+		names = append(names, pathNames(f.Type, seen)...)
+	}
+	return names
+}
+
+// checkMiddleware wraps a handler with each middleware which must not panic
+// or return nil.
+func (r *API) checkMiddleware() []error {
+	var errs []error
+	terminal := http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})
+
+	for i, mw := range r.mw {
+		if mw == nil {
+			errs = append(errs, fmt.Errorf("japi: middleware %d is nil", i))
+			continue
+		}
+		if err := wrapsHandler(mw, terminal); err != nil {
+			errs = append(errs, fmt.Errorf("japi: middleware %d %w", i, err))
+		}
+	}
+
+	return errs
+}
+
+func wrapsHandler(mw Middleware, next http.Handler) (err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			err = fmt.Errorf("panics wrapping a handler: %v", v)
+		}
+	}()
+
+	if mw(next) == nil {
+		return errors.New("returns a nil handler")
+	}
+	return nil
+}
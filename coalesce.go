@@ -0,0 +1,122 @@
+package japi
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// coalescer shares the response of a GET request in flight with the identical
+// requests that arrive before it finishes.
+type coalescer struct {
+	key   func(r *http.Request) string
+	mu    sync.Mutex
+	calls map[string]*coalescedCall
+}
+
+type coalescedCall struct {
+	done   chan struct{}
+	header http.Header
+	res    *StoredResponse
+}
+
+// WithCoalescing handles concurrent identical GET requests of the route once
+// and sends the response to all of them, reducing the load of expensive reads.
+// Requests are identical when they have the same path, query, tenant, Accept,
+// Accept-Language, Accept-Encoding and version headers, the same values of the
+// headers the response varies on and the same key. The key defaults to the
+// Authorization and Cookie headers so responses are only shared between
+// requests with the same credentials. Responses setting cookies and those of
+// requests cancelled before they finish are not shared.
+func WithCoalescing(key func(r *http.Request) string) RouteOption {
+	if key == nil {
+		key = credentials
+	}
+	return func(rt *Route) {
+		rt.coalescer = &coalescer{key: key, calls: map[string]*coalescedCall{}}
+	}
+}
+
+// coalesce wraps the handle to share the responses of identical requests.
+func coalesce(ref *configRef, c *coalescer, next httprouter.Handle) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+		if r.Method != http.MethodGet {
+			next(w, r, p)
+			return
+		}
+
+		key := c.requestKey(ref.Load().forTenant(r.Context()), r)
+		c.mu.Lock()
+		if call, ok := c.calls[key]; ok {
+			c.mu.Unlock()
+			select {
+			case <-call.done:
+			case <-r.Context().Done():
+				return // the client is gone
+			}
+			if call.res == nil || !sameVary(call.res.Header, call.header, r.Header) {
+				next(w, r, p) // the response is not shared
+				return
+			}
+			writeStored(w, call.res)
+			return
+		}
+
+		call := &coalescedCall{done: make(chan struct{}), header: r.Header}
+		c.calls[key] = call
+		c.mu.Unlock()
+
+		defer func() {
+			c.mu.Lock()
+			delete(c.calls, key)
+			c.mu.Unlock()
+			close(call.done)
+		}()
+
+		rec := &recordWriter{statusWriter: newStatusWriter(w)}
+		next(rec, r, p)
+
+		header := rec.header
+		if header == nil {
+			header = w.Header().Clone()
+		}
+		if r.Context().Err() != nil || len(header.Values("Set-Cookie")) > 0 {
+			return
+		}
+		call.res = &StoredResponse{Status: rec.Status(), Header: header, Body: rec.body.Bytes()}
+	}
+}
+
+// requestKey returns the key of the identical requests.
+func (c *coalescer) requestKey(config *Config, r *http.Request) string {
+	var b strings.Builder
+	b.WriteString(r.URL.RequestURI())
+	b.WriteByte(0)
+	b.WriteString(Tenant(r.Context()))
+	for _, name := range []string{config.Versioning.header(), "Accept", "Accept-Language", "Accept-Encoding"} {
+		b.WriteByte(0)
+		b.WriteString(strings.Join(r.Header.Values(name), ","))
+	}
+	b.WriteByte(0)
+	b.WriteString(c.key(r))
+	return b.String()
+}
+
+// sameVary reports whether the requests have the same values of the headers
+// the response varies on.
+func sameVary(res, a, b http.Header) bool {
+	for _, v := range res.Values("Vary") {
+		for _, name := range strings.Split(v, ",") {
+			name = strings.TrimSpace(name)
+			if name == "*" {
+				return false
+			}
+			if strings.Join(a.Values(name), ",") != strings.Join(b.Values(name), ",") {
+				return false
+			}
+		}
+	}
+	return true
+}
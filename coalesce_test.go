@@ -0,0 +1,92 @@
+package japi
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCoalescing(t *testing.T) {
+	tests := []struct {
+		name     string
+		leader   http.Header
+		follower http.Header
+		query    string
+		// concurrent is when the follower is not identical so it is handled
+		// while the leader is in flight.
+		concurrent bool
+		shared     bool
+	}{
+		{"same credentials", http.Header{"Authorization": {"alice"}}, http.Header{"Authorization": {"alice"}}, "", false, true},
+		{"no credentials", http.Header{}, http.Header{}, "", false, true},
+		{"other authorization", http.Header{"Authorization": {"alice"}}, http.Header{"Authorization": {"bob"}}, "", true, false},
+		{"authorization and none", http.Header{"Authorization": {"alice"}}, http.Header{}, "", true, false},
+		{"other cookie", http.Header{"Cookie": {"session=a"}}, http.Header{"Cookie": {"session=b"}}, "", true, false},
+		{"other language", http.Header{"Accept-Language": {"en"}}, http.Header{"Accept-Language": {"fr"}}, "", true, false},
+		{"sets a cookie", http.Header{}, http.Header{}, "?cookie=1", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			started, release := make(chan struct{}, 2), make(chan struct{})
+
+			r := New(nil)
+			r.UpdateConfig(func(c *Config) { c.Logger = nil })
+			r.HandleFunc("GET", "/report", func(w http.ResponseWriter, req *http.Request) {
+				n := calls.Add(1)
+				started <- struct{}{}
+				<-release
+				if req.URL.Query().Get("cookie") != "" {
+					http.SetCookie(w, &http.Cookie{Name: "seen", Value: "1"})
+				}
+				fmt.Fprintf(w, "%d %s", n, req.Header.Get("Authorization"))
+			}, WithCoalescing(nil))
+			h := r.Router()
+
+			serve := func(header http.Header) chan *httptest.ResponseRecorder {
+				res := make(chan *httptest.ResponseRecorder, 1)
+				req := httptest.NewRequest("GET", "/report"+tt.query, nil)
+				req.Header = header
+				go func() {
+					w := httptest.NewRecorder()
+					h.ServeHTTP(w, req)
+					res <- w
+				}()
+				return res
+			}
+
+			leader := serve(tt.leader)
+			<-started
+			follower := serve(tt.follower)
+			if tt.concurrent {
+				select {
+				case <-started:
+				case <-time.After(time.Second):
+					t.Fatal("the follower is not handled while the leader is in flight")
+				}
+			} else {
+				time.Sleep(50 * time.Millisecond) // the follower waits for the leader
+			}
+			close(release)
+
+			lw, fw := <-leader, <-follower
+			if lw.Code != 200 || fw.Code != 200 {
+				t.Fatalf("status %d and %d", lw.Code, fw.Code)
+			}
+			if shared := lw.Body.String() == fw.Body.String(); shared != tt.shared {
+				t.Errorf("shared %v, want %v: %q and %q", shared, tt.shared, lw.Body, fw.Body)
+			}
+			want := int32(2)
+			if tt.shared {
+				want = 1
+			}
+			if n := calls.Load(); n != want {
+				t.Errorf("handled %d times, want %d", n, want)
+			}
+		})
+	}
+}
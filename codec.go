@@ -0,0 +1,110 @@
+package japi
+
+import (
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Encoder encodes the response body.
+type Encoder = func(w io.Writer, v any) error
+
+type typedEncoder struct {
+	encode Encoder
+	match  func(t reflect.Type) bool
+}
+
+var encoders = map[string]typedEncoder{}
+
+// RegisterEncoder registers a response encoder used when the request Accept
+// header prefers the content type. Responses are json otherwise.
+func RegisterEncoder(contentType string, enc Encoder) {
+	encoders[contentType] = typedEncoder{encode: enc}
+}
+
+// RegisterTypedEncoder registers a response encoder that only encodes the
+// types it matches e.g. protobuf messages. The responses of other types are
+// negotiated as if it was not registered.
+func RegisterTypedEncoder(contentType string, enc Encoder, match func(t reflect.Type) bool) {
+	encoders[contentType] = typedEncoder{encode: enc, match: match}
+}
+
+// getResponseEncoder returns the registered encoder of the media type the
+// request accepts with the highest quality for the response type, or nil when
+// json is preferred. Json is matched by application/json and the wildcards.
+func getResponseEncoder(r *http.Request, t reflect.Type) (string, Encoder) {
+	if len(encoders) == 0 {
+		return "", nil
+	}
+
+	best, bestType, bestQ := Encoder(nil), "", 0.0
+	for _, accept := range r.Header.Values("Accept") {
+		for _, part := range strings.Split(accept, ",") {
+			mt, params, err := mime.ParseMediaType(part)
+			if err != nil {
+				continue
+			}
+
+			var enc Encoder
+			switch mt {
+			case JsonEncoding, "application/*", "*/*":
+			default:
+				e, ok := encoders[mt]
+				if !ok || e.match != nil && (t == nil || !e.match(t)) {
+					continue
+				}
+				enc = e.encode
+			}
+
+			q := 1.0
+			if v, ok := params["q"]; ok {
+				if q, err = strconv.ParseFloat(v, 64); err != nil {
+					continue
+				}
+			}
+			if q > bestQ {
+				best, bestType, bestQ = enc, mt, q
+			}
+		}
+	}
+	if best == nil {
+		return "", nil
+	}
+	return bestType, best
+}
+
+// JSONCodec overrides the json encoding of the types it matches e.g. to use
+// protojson for protobuf messages.
+type JSONCodec struct {
+	// Match reports whether the codec encodes the type.
+	Match func(t reflect.Type) bool
+	// Marshal encodes the value.
+	Marshal func(v any) ([]byte, error)
+	// Unmarshal decodes the data into the pointer.
+	Unmarshal func(data []byte, v any) error
+}
+
+var jsonCodecs []JSONCodec
+
+// RegisterJSONCodec registers the codec. Codecs must be registered before the
+// handlers are created.
+func RegisterJSONCodec(c JSONCodec) {
+	jsonCodecs = append(jsonCodecs, c)
+}
+
+// jsonCodecFor returns the codec for the type or nil.
+func jsonCodecFor(t reflect.Type) *JSONCodec {
+	if t == nil {
+		return nil
+	}
+
+	for i := range jsonCodecs {
+		if jsonCodecs[i].Match(t) {
+			return &jsonCodecs[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+// Package codec registers the MessagePack and CBOR codecs with japi. Import it
+// for its side effects to accept and send binary bodies between your internal
+// services.
+//
+//	import _ "github.com/jarrettv/go-japi/codec"
+//
+// Requests are decoded by their Content-Type and responses are encoded when the
+// request Accept header has the media type. Both codecs use the json struct
+// tags so the same request and response types work for every encoding.
+package codec
+
+import (
+	"io"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/vmihailenco/msgpack/v5"
+
+	"github.com/jarrettv/go-japi"
+)
+
+// The media types of the codecs.
+const (
+	MsgpackEncoding = "application/msgpack"
+	CBOREncoding    = "application/cbor"
+)
+
+func init() {
+	japi.RegisterDecoder(MsgpackEncoding, newMsgpackDecoder, "application/x-msgpack")
+	japi.RegisterEncoder(MsgpackEncoding, encodeMsgpack)
+	japi.RegisterEncoder("application/x-msgpack", encodeMsgpack)
+
+	japi.RegisterDecoder(CBOREncoding, newCBORDecoder)
+	japi.RegisterEncoder(CBOREncoding, encodeCBOR)
+}
+
+func newMsgpackDecoder(r io.Reader) interface{ Decode(interface{}) error } {
+	dec := msgpack.NewDecoder(r)
+	dec.SetCustomStructTag("json")
+	return dec
+}
+
+func encodeMsgpack(w io.Writer, v any) error {
+	enc := msgpack.NewEncoder(w)
+	enc.SetCustomStructTag("json")
+	return enc.Encode(v)
+}
+
+func newCBORDecoder(r io.Reader) interface{ Decode(interface{}) error } {
+	return cbor.NewDecoder(r)
+}
+
+func encodeCBOR(w io.Writer, v any) error {
+	return cbor.NewEncoder(w).Encode(v)
+}
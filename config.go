@@ -3,37 +3,88 @@ package japi
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
+	"net/http"
+	"net/netip"
+	"sync"
+	"sync/atomic"
 	"time"
 
-	"github.com/goccy/go-json"
-
+	"github.com/jarrettv/go-japi/metrics"
 	"github.com/jarrettv/go-japi/problem"
 )
 
 type Config struct {
 	// the function to call for logging route details
 	RouteLogFunc func(ctx context.Context, route string, params map[string]string)
+	// the function to call for logging requests of deprecated routes, the sunset is zero when not set
+	DeprecationLogFunc func(ctx context.Context, route string, sunset time.Time)
 	// the function to call for logging problems
 	ProblemLogFunc func(ctx context.Context, p *problem.Problem)
+	// the logger for requests and problems when the log funcs are nil
+	Logger *slog.Logger
+	// the function to scrub problems after they are logged and before they are served
+	ProblemSanitizer func(ctx context.Context, p *problem.Problem)
+	// the function to localize problems in the locales of the request ordered by preference
+	ProblemTranslator func(ctx context.Context, locales []string, p *problem.Problem)
+	// the flag to serve unexpected error messages in the problem detail
+	ExposeInternalErrors bool
+	// the hook to call before decoding requests
+	BeforeDecode DecodeHook
+	// the hook to call after decoding requests
+	AfterDecode DecodeHook
+	// the validator to use instead of the validate tags e.g. go-playground/validator
+	Validator func(v any) problem.Errors
+	// the flag to check responses with their validate tags and response schema
+	ValidateResponses bool
+	// the function to wrap response bodies in a standard envelope, it gets the
+	// response value so tag the fields only sent as headers json:"-"
+	ResponseWrapper func(ctx context.Context, route string, body any) any
+	// the flag to reuse the decoded request values, handlers must not keep a pointer to the request
+	ReuseRequests bool
+	// the max size in bytes of request bodies, zero is unlimited
+	MaxBodyBytes int64
+	// the flag to reject json bodies with fields not in the request
+	DisallowUnknownFields bool
+	// the options for encoding json responses
+	Encode EncodeOptions
+	// the func to marshal json responses e.g. encoding/json, nil uses goccy/go-json
+	JSONMarshal func(v any) ([]byte, error)
+	// the func to unmarshal json request bodies e.g. encoding/json, nil uses goccy/go-json
+	JSONUnmarshal func(data []byte, v any) error
+	// the outputs to reshape responses when the request accepts their media type
+	Outputs []Output
+	// the recorder for request metrics, nil disables metrics
+	Metrics metrics.Recorder
+	// the tracer to start spans for requests, nil disables tracing
+	Tracer Tracer
+	// the max requests handled at once by the API, zero is unlimited
+	MaxInFlight int
+	// the flag to keep the start of each request in flight for Stats.Oldest and the shutdown logs
+	TrackRequests bool
+	// the Retry-After of the problem when a concurrency limit is saturated, defaults to 1s
+	RetryAfter time.Duration
+	// the reporter of 5xx problems to error tracking e.g. Sentry
+	ErrorReporter ErrorReporter
+	// the auditor to record requests, nil disables auditing
+	Audit *Auditor
+	// the negotiation of versioned routes
+	Versioning Versioning
+	// the bounds of the timeouts callers set with a header, zero Max ignores the header
+	RequestTimeouts RequestTimeouts
+	// the proxies trusted to forward the client IP e.g. netip.MustParsePrefix("10.0.0.0/8")
+	TrustedProxies []netip.Prefix
+	// the resolution of the request tenants, nil disables tenancy
+	Tenancy *Tenancy
 	problem.ProblemConfig
 }
 
 // GetDefaultConfig will return default problem config.
 func GetDefaultConfig() *Config {
 	return &Config{
-		RouteLogFunc: func(ctx context.Context, route string, params map[string]string) {
-			if params != nil && len(params) > 0 {
-				data, err := json.Marshal(params)
-				if err == nil {
-					log.Printf("%s %s", route, string(data))
-					return
-				}
-			}
-			log.Print(route)
-		},
-		ProblemLogFunc: func(ctx context.Context, p *problem.Problem) {
-			log.Printf("%v type=%v", p.Title, p.Type)
+		Logger: slog.Default(),
+		Encode: EncodeOptions{
+			PrettyQuery: "pretty",
 		},
 		ProblemConfig: problem.ProblemConfig{
 			ProblemTypeUrlFormat: "https://example.com/errors/%s",
@@ -43,3 +94,66 @@ func GetDefaultConfig() *Config {
 		},
 	}
 }
+
+// configRef is the config shared by the API and its handlers which can be
+// swapped while serving.
+type configRef struct {
+	mu sync.Mutex
+	p  atomic.Pointer[Config]
+}
+
+func newConfigRef(c *Config) *configRef {
+	ref := &configRef{}
+	ref.p.Store(c)
+	return ref
+}
+
+// Load returns the current config.
+func (ref *configRef) Load() *Config {
+	return ref.p.Load()
+}
+
+// update swaps the config for a copy changed by the func.
+func (ref *configRef) update(fn func(c *Config)) (old, updated *Config) {
+	ref.mu.Lock()
+	defer ref.mu.Unlock()
+
+	old = ref.p.Load()
+	c := *old
+	fn(&c)
+	ref.p.Store(&c)
+	return old, &c
+}
+
+// serveProblem will enrich, log and serve the problem.
+func (c *Config) serveProblem(w http.ResponseWriter, r *http.Request, p *problem.Problem) {
+	c.prepareProblem(withRequest(r), p)
+	p.Serve(w, r)
+}
+
+// prepareProblem will enrich, log and sanitize the problem before it is served.
+func (c *Config) prepareProblem(ctx context.Context, p *problem.Problem) {
+	c = c.forTenant(ctx)
+	internal := p.Type == "unexpected"
+
+	c.Enrich(ctx, p)
+	if s := spanFrom(ctx); s != nil {
+		s.Problem(p)
+	}
+	if c.ProblemLogFunc != nil {
+		c.ProblemLogFunc(ctx, p)
+	} else if c.Logger != nil {
+		c.logProblem(ctx, p)
+	}
+	c.report(ctx, p)
+
+	if internal && !c.ExposeInternalErrors {
+		p.Detail = "An unexpected error occurred"
+	}
+	if c.ProblemTranslator != nil {
+		c.ProblemTranslator(ctx, Locales(ctx), p)
+	}
+	if c.ProblemSanitizer != nil {
+		c.ProblemSanitizer(ctx, p)
+	}
+}
@@ -0,0 +1,103 @@
+package japi
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// registration is a method and path registered on the router.
+type registration struct {
+	method string
+	path   string
+	site   string
+	mount  string // the path of a mounted API
+}
+
+func (reg registration) String() string {
+	if reg.mount != "" {
+		return "the API mounted at " + reg.mount
+	}
+	return "route " + reg.method + " " + reg.path
+}
+
+// register checks the path does not conflict with the registered paths before
+// handling it. httprouter panics on conflicts without saying where the other
+// route was registered so the panic has both call sites.
+func (r *API) register(method, path string, hh httprouter.Handle) {
+	r.add(registration{method: method, path: path}, hh)
+}
+
+// registerMount registers the catch-all path of the API mounted at the prefix.
+func (r *API) registerMount(method, prefix string, hh httprouter.Handle) {
+	r.add(registration{method: method, path: prefix + "/*mount", mount: prefix + "/"}, hh)
+}
+
+func (r *API) add(reg registration, hh httprouter.Handle) {
+	reg.site = callSite()
+	for _, other := range r.registered {
+		if other.method != reg.method {
+			continue
+		}
+
+		switch conflict(other.path, reg.path) {
+		case pathDuplicate:
+			panic(fmt.Sprintf("japi: %s at %s is already registered at %s",
+				reg, reg.site, other.site))
+		case pathConflict:
+			panic(fmt.Sprintf("japi: %s at %s conflicts with %s at %s",
+				reg, reg.site, other, other.site))
+		}
+	}
+
+	r.router.Handle(reg.method, reg.path, hh)
+	r.registered = append(r.registered, reg)
+}
+
+const (
+	pathDistinct = iota
+	pathDuplicate
+	pathConflict
+)
+
+// conflict compares the paths segment by segment. Paths conflict when a
+// wildcard shares a position with a different segment.
+func conflict(a, b string) int {
+	as, bs := strings.Split(a, "/"), strings.Split(b, "/")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if as[i] == bs[i] {
+			continue
+		}
+		if isWildcard(as[i]) || isWildcard(bs[i]) {
+			return pathConflict
+		}
+		return pathDistinct
+	}
+
+	if len(as) == len(bs) {
+		return pathDuplicate
+	}
+	return pathDistinct
+}
+
+func isWildcard(seg string) bool {
+	return seg != "" && (seg[0] == ':' || seg[0] == '*')
+}
+
+// callSite returns the file and line of the first caller outside of japi.
+func callSite() string {
+	pcs := make([]uintptr, 16)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs)])
+	for {
+		f, more := frames.Next()
+		if !strings.HasPrefix(f.Function, "github.com/jarrettv/go-japi.") {
+			return fmt.Sprintf("%s:%d", filepath.Base(f.File), f.Line)
+		}
+		if !more {
+			return "unknown"
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package japi
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jarrettv/go-japi/problem"
+)
+
+// cronJob is a handler run on a schedule while the API is served.
+type cronJob struct {
+	spec     string
+	schedule *schedule
+	run      func(ctx context.Context) error
+}
+
+// Cron runs the func on the cron schedule while the API is served. The spec has
+// the minute, hour, day of month, month and day of week fields e.g. */5 * * * *
+// or is one of @hourly, @daily, @weekly, @monthly and @yearly. Runs of a func
+// never overlap. The errors are logged and reported like the problems of the
+// handlers. It panics when the spec is invalid.
+func (r *API) Cron(spec string, run func(ctx context.Context) error) {
+	s, err := parseSchedule(spec)
+	if err != nil {
+		panic(fmt.Sprintf("japi: invalid cron %q: %v", spec, err))
+	}
+	r.crons = append(r.crons, &cronJob{spec: spec, schedule: s, run: run})
+}
+
+// startCron runs the schedules until the context is done. The wait group is
+// done when the running funcs are finished.
+func (r *API) startCron(ctx context.Context, wg *sync.WaitGroup) {
+	for _, job := range r.crons {
+		wg.Add(1)
+		go func(job *cronJob) {
+			defer wg.Done()
+			for {
+				next := job.schedule.next(time.Now())
+				if next.IsZero() {
+					return
+				}
+
+				timer := time.NewTimer(time.Until(next))
+				select {
+				case <-ctx.Done():
+					timer.Stop()
+					return
+				case <-timer.C:
+					r.runCron(ctx, job)
+				}
+			}
+		}(job)
+	}
+}
+
+func (r *API) runCron(ctx context.Context, job *cronJob) {
+	err := func() (err error) {
+		defer func() {
+			if v := recover(); v != nil {
+				err = fmt.Errorf("japi: cron panic: %v", v)
+			}
+		}()
+		return job.run(ctx)
+	}()
+	if err == nil {
+		return
+	}
+
+	p, ok := asProblem(err)
+	if !ok {
+		p = problem.Unexpected(fmt.Errorf("japi: cron %q: %w", job.spec, err))
+	}
+	r.config.Load().prepareProblem(ctx, p)
+}
+
+// schedule has a bit for each matching value of the cron fields.
+type schedule struct {
+	minute, hour, dom, month, dow uint64
+	anyDay                        bool // the day of month or day of week is *
+}
+
+var cronDescriptors = map[string]string{
+	"@yearly":  "0 0 1 1 *",
+	"@monthly": "0 0 1 * *",
+	"@weekly":  "0 0 * * 0",
+	"@daily":   "0 0 * * *",
+	"@hourly":  "0 * * * *",
+}
+
+func parseSchedule(spec string) (*schedule, error) {
+	if d, ok := cronDescriptors[spec]; ok {
+		spec = d
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("expected 5 fields but got %d", len(fields))
+	}
+
+	s := &schedule{anyDay: fields[2] == "*" || fields[4] == "*"}
+	for i, f := range []struct {
+		bits     *uint64
+		min, max int
+	}{{&s.minute, 0, 59}, {&s.hour, 0, 23}, {&s.dom, 1, 31}, {&s.month, 1, 12}, {&s.dow, 0, 7}} {
+		bits, err := parseCronField(fields[i], f.min, f.max)
+		if err != nil {
+			return nil, err
+		}
+		*f.bits = bits
+	}
+
+	// sunday is 0 or 7
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+	return s, nil
+}
+
+// parseCronField parses the comma separated values, ranges and steps e.g.
+// 1,15-20,*/10.
+func parseCronField(field string, min, max int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		expr, stepStr, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepStr)
+			if err != nil || n < 1 {
+				return 0, fmt.Errorf("invalid step %q", part)
+			}
+			step = n
+		}
+
+		lo, hi := min, max
+		if expr != "*" {
+			from, to, isRange := strings.Cut(expr, "-")
+			var err error
+			if lo, err = strconv.Atoi(from); err != nil {
+				return 0, fmt.Errorf("invalid value %q", part)
+			}
+			hi = lo
+			if isRange {
+				if hi, err = strconv.Atoi(to); err != nil {
+					return 0, fmt.Errorf("invalid range %q", part)
+				}
+			} else if hasStep {
+				hi = max
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return 0, fmt.Errorf("%q is out of the range %d-%d", part, min, max)
+		}
+
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << v
+		}
+	}
+	return bits, nil
+}
+
+// next returns the first matching minute after t or the zero time when there
+// is none within five years.
+func (s *schedule) next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		switch {
+		case s.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !s.matchDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case s.hour&(1<<uint(t.Hour())) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case s.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// matchDay matches either day field when both are restricted like cron.
+func (s *schedule) matchDay(t time.Time) bool {
+	dom := s.dom&(1<<uint(t.Day())) != 0
+	dow := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.anyDay {
+		return dom && dow
+	}
+	return dom || dow
+}
@@ -0,0 +1,77 @@
+package japi
+
+import (
+	"context"
+	"expvar"
+	"net/http"
+	"net/http/pprof"
+	"strings"
+
+	"github.com/jarrettv/go-japi/problem"
+)
+
+// DebugOption configures the debug endpoints.
+type DebugOption func(*debugConfig)
+
+type debugConfig struct {
+	auth func(r *http.Request) bool
+}
+
+// DebugAuth protects the debug endpoints, responding with 403 Forbidden when
+// the func returns false.
+func DebugAuth(fn func(r *http.Request) bool) DebugOption {
+	return func(c *debugConfig) {
+		c.auth = fn
+	}
+}
+
+// EnableDebug registers the pprof endpoints under prefix/pprof/, the expvar
+// endpoint at prefix/vars and the Stats of the requests in flight at
+// prefix/requests.
+func (r *API) EnableDebug(prefix string, opts ...DebugOption) {
+	c := &debugConfig{}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	prefix = strings.TrimSuffix(prefix, "/")
+	guard := func(h http.Handler) http.Handler {
+		if c.auth == nil {
+			return h
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if !c.auth(req) {
+				r.config.Load().serveProblem(w, req, problem.Status(http.StatusForbidden))
+				return
+			}
+			h.ServeHTTP(w, req)
+		})
+	}
+
+	// httprouter does not allow the named pprof paths next to a catch-all so
+	// the profile is dispatched by name
+	profiles := guard(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		name := strings.TrimPrefix(req.URL.Path, prefix+"/pprof/")
+		switch name {
+		case "":
+			pprof.Index(w, req)
+		case "cmdline":
+			pprof.Cmdline(w, req)
+		case "profile":
+			pprof.Profile(w, req)
+		case "symbol":
+			pprof.Symbol(w, req)
+		case "trace":
+			pprof.Trace(w, req)
+		default:
+			pprof.Handler(name).ServeHTTP(w, req)
+		}
+	}))
+
+	r.register(http.MethodGet, prefix+"/pprof/*profile", wrapHandler(profiles))
+	r.register(http.MethodPost, prefix+"/pprof/*profile", wrapHandler(profiles))
+	r.Get(prefix+"/vars", guard(expvar.Handler()))
+	r.Get(prefix+"/requests", guard(withConfig(H(func(context.Context, Empty) (Stats, error) {
+		return r.Stats(), nil
+	}), r.config)))
+}
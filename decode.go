@@ -2,16 +2,23 @@ package japi
 
 import (
 	"context"
+	stdjson "encoding/json"
 	"errors"
 	"io"
 	"io/ioutil"
 	"net/http"
+	"reflect"
 
 	"github.com/goccy/go-json"
+
+	"github.com/jarrettv/go-japi/decoder"
+	"github.com/jarrettv/go-japi/problem"
 )
 
 func init() {
 	RegisterDecoder(JsonEncoding, decodeJSON)
+	RegisterDecoder(MergePatchEncoding, decodeJSON)
+	RegisterDecoder(JsonPatchEncoding, decodeJSON)
 }
 
 type (
@@ -74,7 +81,83 @@ func getDecoder(mime string) (RequestParser, error) {
 	return nil, errors.New("decoder not found")
 }
 
+// addDecodeError adds the decode error to the field errors using the
+// source as the field when it is not known.
+func addDecodeError(errs problem.Errors, source string, e error) {
+	var fes decoder.Errors
+	var fe *decoder.FieldError
+	var te *json.UnmarshalTypeError
+	var se *json.SyntaxError
+	var ste *stdjson.UnmarshalTypeError
+	var sse *stdjson.SyntaxError
+
+	switch {
+	case errors.As(e, &fes):
+		for _, fe := range fes {
+			field := fe.Field
+			if field == "" {
+				field = source
+			}
+			errs.Add(field, fe.Message())
+		}
+	case errors.As(e, &fe):
+		errs.Add(fe.Field, fe.Message())
+	case errors.As(e, &te):
+		field := te.Field
+		if field == "" {
+			field = source
+		}
+		errs.Add(field, "must be "+te.Type.String())
+	case errors.As(e, &ste):
+		field := ste.Field
+		if field == "" {
+			field = source
+		}
+		errs.Add(field, "must be "+ste.Type.String())
+	case errors.As(e, &se), errors.As(e, &sse), errors.Is(e, io.ErrUnexpectedEOF):
+		errs.Add(source, "malformed json")
+	default:
+		errs.Add(source, e.Error())
+	}
+}
+
+// unmarshalJSON returns the json decoder using the unmarshal func. The types
+// of the registered json codecs are still decoded by their codec.
+func unmarshalJSON(unmarshal func(data []byte, v any) error) RequestParser {
+	return func(r *http.Request, v interface{}) error {
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			return err
+		}
+		return unmarshalWith(unmarshal, data, v)
+	}
+}
+
+// unmarshalWith decodes the json with the codec registered for the type or
+// the unmarshal func, which defaults to goccy/go-json.
+func unmarshalWith(unmarshal func(data []byte, v any) error, data []byte, v any) error {
+	if t := reflect.TypeOf(v); t.Kind() == reflect.Pointer {
+		if c := jsonCodecFor(t.Elem()); c != nil {
+			return c.Unmarshal(data, v)
+		}
+	}
+	if unmarshal == nil {
+		unmarshal = json.Unmarshal
+	}
+	return unmarshal(data, v)
+}
+
 func decodeJSON(r *http.Request, v interface{}) error {
+	if t := reflect.TypeOf(v); t.Kind() == reflect.Pointer {
+		if c := jsonCodecFor(t.Elem()); c != nil {
+			data, err := io.ReadAll(r.Body)
+			if err != nil {
+				return err
+			}
+			return c.Unmarshal(data, v)
+		}
+	}
+
 	return json.NewDecoder(r.Body).DecodeContext(r.Context(), v)
 }
 
@@ -0,0 +1,186 @@
+package japi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"runtime/metrics"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jarrettv/go-japi/problem"
+)
+
+type itemsRequest struct {
+	Name  string `json:"name"`
+	Items []int  `json:"items"`
+}
+
+// nameValidator rejects the bodies with a name that is not a string like the
+// JSON Schema {"properties": {"name": {"type": "string"}}}.
+type nameValidator struct{}
+
+func (nameValidator) ValidateBody(body io.Reader, errs problem.Errors) error {
+	var doc map[string]any
+	if err := json.NewDecoder(body).Decode(&doc); err != nil {
+		return err
+	}
+	if _, ok := doc["name"].(string); !ok && doc["name"] != nil {
+		errs.Add("/name", "got number, want string")
+	}
+	return nil
+}
+
+// jsonStream is a json object of the size with an array of ones which is
+// generated as it is read.
+type jsonStream struct {
+	prefix, suffix string
+	size, n        int
+}
+
+func newJSONStream(field string, size int) *jsonStream {
+	return &jsonStream{prefix: `{"name":"x","` + field + `":[1`, suffix: `]}`, size: size}
+}
+
+func (s *jsonStream) Read(p []byte) (int, error) {
+	i := 0
+	for ; i < len(p); i++ {
+		switch {
+		case s.n < len(s.prefix):
+			p[i] = s.prefix[s.n]
+		case s.n < s.size-len(s.suffix):
+			p[i] = ",1"[(s.n-len(s.prefix))%2]
+		case s.n < s.size:
+			p[i] = s.suffix[s.n-(s.size-len(s.suffix))]
+		default:
+			if i == 0 {
+				return 0, io.EOF
+			}
+			return i, nil
+		}
+		s.n++
+	}
+	return i, nil
+}
+
+func newItemsHandler(max int64, opts ...RouteOption) http.Handler {
+	r := New(nil)
+	r.UpdateConfig(func(c *Config) {
+		c.Logger = nil
+		c.MaxBodyBytes = max
+	})
+	r.Post("/items", H(func(_ context.Context, req itemsRequest) (*Empty, error) {
+		return &Empty{}, nil
+	}), opts...)
+	return r.Router()
+}
+
+func TestDecodeBodyChecks(t *testing.T) {
+	h := newItemsHandler(1<<20, WithDisallowUnknownFields(true), WithRequestValidator(nameValidator{}))
+
+	tests := []struct {
+		name    string
+		body    io.Reader
+		status  int
+		contain string
+	}{
+		{"valid", strings.NewReader(`{"name":"x","items":[1]}`), 200, ""},
+		{"chunked", io.MultiReader(strings.NewReader(`{"name":"x"}`)), 200, ""},
+		{"empty chunked", io.MultiReader(), 200, ""},
+		{"unknown fields", strings.NewReader(`{"name":"x","b":1,"a":{"c":1}}`), 400, `"a":["unknown field"],"b":["unknown field"]`},
+		{"validator", strings.NewReader(`{"name":1,"items":["a"]}`), 400, `"/name":`},
+		{"malformed", strings.NewReader(`{"name":`), 400, "malformed json"},
+		{"too large", newJSONStream("items", 2<<20), 413, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/items", tt.body)
+			req.Header.Set("Content-Type", JsonEncoding)
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+
+			if w.Code != tt.status {
+				t.Fatalf("status %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+			if !strings.Contains(w.Body.String(), tt.contain) {
+				t.Errorf("body %s does not contain %s", w.Body, tt.contain)
+			}
+			if strings.Contains(tt.name, "validator") && strings.Contains(w.Body.String(), `"items"`) {
+				t.Errorf("body %s has the decode errors of a body the validator rejects", w.Body)
+			}
+		})
+	}
+}
+
+// BenchmarkDecodeOversized shows the memory of rejecting a chunked body over
+// the 1MB limit stays flat as the body grows since it is aborted at the limit.
+func BenchmarkDecodeOversized(b *testing.B) {
+	benchmarkDecode(b, 1<<20, "items", []int{4 << 20, 16 << 20, 64 << 20})
+}
+
+// BenchmarkDecodeUnknown shows checking the unknown fields of a large body
+// adds no buffering to BenchmarkDecodeIgnored since the body is streamed to
+// the check. The json decoder still buffers the value it decodes.
+func BenchmarkDecodeUnknown(b *testing.B) {
+	benchmarkDecode(b, -1, "pad", []int{1 << 20, 4 << 20, 16 << 20}, WithDisallowUnknownFields(true))
+}
+
+// BenchmarkDecodeIgnored is the baseline of decoding a large body with a
+// field the request type ignores without checks.
+func BenchmarkDecodeIgnored(b *testing.B) {
+	benchmarkDecode(b, -1, "pad", []int{1 << 20, 4 << 20, 16 << 20})
+}
+
+// benchmarkDecode reports the peak heap of handling the generated bodies of
+// the sizes, which is what stays flat, along with the allocations.
+func benchmarkDecode(b *testing.B, max int64, field string, sizes []int, opts ...RouteOption) {
+	h := newItemsHandler(max, opts...)
+
+	for _, size := range sizes {
+		b.Run(fmt.Sprintf("%dMB", size>>20), func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(size))
+			runtime.GC()
+			base := heapBytes()
+
+			var peak atomic.Uint64
+			done := make(chan struct{})
+			go func() {
+				for {
+					if n := heapBytes(); n > peak.Load() {
+						peak.Store(n)
+					}
+					select {
+					case <-done:
+						return
+					case <-time.After(100 * time.Microsecond):
+					}
+				}
+			}()
+
+			for i := 0; i < b.N; i++ {
+				req := httptest.NewRequest("POST", "/items", newJSONStream(field, size))
+				req.Header.Set("Content-Type", JsonEncoding)
+				h.ServeHTTP(httptest.NewRecorder(), req)
+			}
+			close(done)
+
+			if p := peak.Load(); p > base {
+				b.ReportMetric(float64(p-base), "peak-heap-B")
+			}
+		})
+	}
+}
+
+func heapBytes() uint64 {
+	s := []metrics.Sample{{Name: "/memory/classes/heap/objects:bytes"}}
+	metrics.Read(s)
+	return s[0].Value.Uint64()
+}
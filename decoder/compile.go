@@ -2,15 +2,70 @@ package decoder
 
 import (
 	"errors"
+	"fmt"
 	"reflect"
 	"strconv"
+	"strings"
 	"unsafe"
 )
 
 var ErrUnsupportedType = errors.New("decoder: unsupported type")
 
+// FieldError is the error decoding the value of the field.
+type FieldError struct {
+	Field string
+	Err   error
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("decoder: %s: %v", e.Field, e.Err)
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// Message returns a short message for the error suitable for clients.
+func (e *FieldError) Message() string {
+	var ne *strconv.NumError
+	if errors.As(e.Err, &ne) {
+		return ne.Err.Error()
+	}
+	return e.Err.Error()
+}
+
+// Errors are the errors decoding the fields. All fields are decoded
+// before the errors are returned.
+type Errors []*FieldError
+
+func (e Errors) Error() string {
+	msgs := make([]string, len(e))
+	for i, fe := range e {
+		msgs[i] = fe.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Optional is implemented by types that track if a value was decoded.
+type Optional interface {
+	// OptionalValue returns a pointer to the wrapped value.
+	OptionalValue() any
+	// SetPresent marks the value as present.
+	SetPresent()
+}
+
+var optionalType = reflect.TypeOf((*Optional)(nil)).Elem()
+
 type decoder func(reflect.Value, Getter) error
 
+// field describes the struct field to set.
+type field struct {
+	index    int
+	ptr      bool
+	typ      reflect.Type
+	optional bool
+}
+
 //nolint:cyclop
 func compile(typ reflect.Type, tagKey string, isPtr bool) (decoder, error) {
 	decoders := []decoder{}
@@ -24,10 +79,39 @@ func compile(typ reflect.Type, tagKey string, isPtr bool) (decoder, error) {
 		t, k, ptr := typeKind(f.Type)
 
 		tag, ok := f.Tag.Lookup(tagKey)
-		if !ok && k != reflect.Struct {
+		if !ok && (k != reflect.Struct || parserFor(t) != nil || !hasTag(t, tagKey, map[reflect.Type]bool{})) {
 			continue
 		}
 
+		fi := field{index: i, ptr: ptr, typ: t}
+
+		if k == reflect.Struct && reflect.PointerTo(t).Implements(optionalType) {
+			if !ok {
+				continue
+			}
+
+			inner := reflect.TypeOf(reflect.New(t).Interface().(Optional).OptionalValue()).Elem()
+			fi.optional = true
+			k = inner.Kind()
+			t = inner
+
+			if k == reflect.Struct && parserFor(t) == nil {
+				return nil, ErrUnsupportedType
+			}
+		}
+
+		if parse := parserFor(t); parse != nil {
+			decoders = append(decoders, decodeValue(setValue(fi), tag, parse))
+			continue
+		}
+
+		if k == reflect.Slice {
+			if parse := parserFor(t.Elem()); parse != nil {
+				decoders = append(decoders, decodeValues(setValue(fi), tag, t, parse))
+				continue
+			}
+		}
+
 		switch k {
 		case reflect.Struct:
 			dec, err := compile(t, tagKey, ptr)
@@ -41,40 +125,40 @@ func compile(typ reflect.Type, tagKey string, isPtr bool) (decoder, error) {
 				return dec(v.Field(index), m)
 			})
 		case reflect.String:
-			decoders = append(decoders, decodeString(set[string](ptr, i, t), tag))
+			decoders = append(decoders, decodeString(set[string](fi), tag))
 		case reflect.Int:
-			decoders = append(decoders, decodeInt(set[int](ptr, i, t), tag))
+			decoders = append(decoders, decodeInt(set[int](fi), tag))
 		case reflect.Int8:
-			decoders = append(decoders, decodeInt8(set[int8](ptr, i, t), tag))
+			decoders = append(decoders, decodeInt8(set[int8](fi), tag))
 		case reflect.Int16:
-			decoders = append(decoders, decodeInt16(set[int16](ptr, i, t), tag))
+			decoders = append(decoders, decodeInt16(set[int16](fi), tag))
 		case reflect.Int32:
-			decoders = append(decoders, decodeInt32(set[int32](ptr, i, t), tag))
+			decoders = append(decoders, decodeInt32(set[int32](fi), tag))
 		case reflect.Int64:
-			decoders = append(decoders, decodeInt64(set[int64](ptr, i, t), tag))
+			decoders = append(decoders, decodeInt64(set[int64](fi), tag))
 		case reflect.Uint:
-			decoders = append(decoders, decodeUint(set[uint](ptr, i, t), tag))
+			decoders = append(decoders, decodeUint(set[uint](fi), tag))
 		case reflect.Uint8:
-			decoders = append(decoders, decodeUint8(set[uint8](ptr, i, t), tag))
+			decoders = append(decoders, decodeUint8(set[uint8](fi), tag))
 		case reflect.Uint16:
-			decoders = append(decoders, decodeUint16(set[uint16](ptr, i, t), tag))
+			decoders = append(decoders, decodeUint16(set[uint16](fi), tag))
 		case reflect.Uint32:
-			decoders = append(decoders, decodeUint32(set[uint32](ptr, i, t), tag))
+			decoders = append(decoders, decodeUint32(set[uint32](fi), tag))
 		case reflect.Uint64:
-			decoders = append(decoders, decodeUint64(set[uint64](ptr, i, t), tag))
+			decoders = append(decoders, decodeUint64(set[uint64](fi), tag))
 		case reflect.Float32:
-			decoders = append(decoders, decodeFloat32(set[float32](ptr, i, t), tag))
+			decoders = append(decoders, decodeFloat32(set[float32](fi), tag))
 		case reflect.Float64:
-			decoders = append(decoders, decodeFloat64(set[float64](ptr, i, t), tag))
+			decoders = append(decoders, decodeFloat64(set[float64](fi), tag))
 		case reflect.Bool:
-			decoders = append(decoders, decodeBool(set[bool](ptr, i, t), tag))
+			decoders = append(decoders, decodeBool(set[bool](fi), tag))
 		case reflect.Slice:
 			_, sk, _ := typeKind(t.Elem())
 			switch sk {
 			case reflect.String:
-				decoders = append(decoders, decodeStrings(set[[]string](ptr, i, t), tag))
+				decoders = append(decoders, decodeStrings(set[[]string](fi), tag))
 			case reflect.Uint8:
-				decoders = append(decoders, decodeBytes(set[[]byte](ptr, i, t), tag))
+				decoders = append(decoders, decodeBytes(set[[]byte](fi), tag))
 			}
 		default:
 			return nil, ErrUnsupportedType
@@ -94,12 +178,24 @@ func compile(typ reflect.Type, tagKey string, isPtr bool) (decoder, error) {
 			v = v.Elem()
 		}
 
+		var errs Errors
 		for _, dec := range decoders {
 			if err := dec(v, d); err != nil {
-				return err
+				switch e := err.(type) {
+				case Errors:
+					errs = append(errs, e...)
+				case *FieldError:
+					errs = append(errs, e)
+				default:
+					errs = append(errs, &FieldError{Err: err})
+				}
 			}
 		}
 
+		if len(errs) > 0 {
+			return errs
+		}
+
 		return nil
 	}, nil
 }
@@ -117,8 +213,26 @@ func typeKind(t reflect.Type) (reflect.Type, reflect.Kind, bool) {
 	return t, k, isPtr
 }
 
-func set[T any](ptr bool, i int, t reflect.Type) func(reflect.Value, T) {
-	if ptr {
+func set[T any](fi field) func(reflect.Value, T) {
+	i, t := fi.index, fi.typ
+
+	if fi.optional {
+		return func(v reflect.Value, d T) {
+			f := v.Field(i)
+			if fi.ptr {
+				if f.IsNil() {
+					f.Set(reflect.New(f.Type().Elem()))
+				}
+				f = f.Elem()
+			}
+
+			o := f.Addr().Interface().(Optional)
+			*(*T)(unsafe.Pointer(reflect.ValueOf(o.OptionalValue()).Pointer())) = d
+			o.SetPresent()
+		}
+	}
+
+	if fi.ptr {
 		return func(v reflect.Value, d T) {
 			f := v.Field(i)
 			if f.IsNil() {
@@ -149,7 +263,7 @@ func decodeInt(set func(reflect.Value, int), k string) decoder {
 		if s := g.Get(k); s != "" {
 			n, err := strconv.Atoi(s)
 			if err != nil {
-				return err
+				return &FieldError{Field: k, Err: err}
 			}
 
 			set(v, n)
@@ -164,7 +278,7 @@ func decodeInt8(set func(reflect.Value, int8), k string) decoder {
 		if s := g.Get(k); s != "" {
 			n, err := strconv.ParseInt(s, 10, 8)
 			if err != nil {
-				return err
+				return &FieldError{Field: k, Err: err}
 			}
 
 			set(v, int8(n))
@@ -179,7 +293,7 @@ func decodeInt16(set func(reflect.Value, int16), k string) decoder {
 		if s := g.Get(k); s != "" {
 			n, err := strconv.ParseInt(s, 10, 16)
 			if err != nil {
-				return err
+				return &FieldError{Field: k, Err: err}
 			}
 
 			set(v, int16(n))
@@ -194,7 +308,7 @@ func decodeInt32(set func(reflect.Value, int32), k string) decoder {
 		if s := g.Get(k); s != "" {
 			n, err := strconv.ParseInt(s, 10, 32)
 			if err != nil {
-				return err
+				return &FieldError{Field: k, Err: err}
 			}
 
 			set(v, int32(n))
@@ -209,7 +323,7 @@ func decodeInt64(set func(reflect.Value, int64), k string) decoder {
 		if s := g.Get(k); s != "" {
 			n, err := strconv.Atoi(s)
 			if err != nil {
-				return err
+				return &FieldError{Field: k, Err: err}
 			}
 
 			set(v, int64(n))
@@ -224,7 +338,7 @@ func decodeFloat32(set func(reflect.Value, float32), k string) decoder {
 		if s := g.Get(k); s != "" {
 			f, err := strconv.ParseFloat(s, 32)
 			if err != nil {
-				return err
+				return &FieldError{Field: k, Err: err}
 			}
 
 			set(v, float32(f))
@@ -239,7 +353,7 @@ func decodeFloat64(set func(reflect.Value, float64), k string) decoder {
 		if s := g.Get(k); s != "" {
 			f, err := strconv.ParseFloat(s, 64)
 			if err != nil {
-				return err
+				return &FieldError{Field: k, Err: err}
 			}
 
 			set(v, f)
@@ -254,7 +368,7 @@ func decodeUint(set func(reflect.Value, uint), k string) decoder {
 		if s := g.Get(k); s != "" {
 			n, err := strconv.ParseUint(s, 10, strconv.IntSize)
 			if err != nil {
-				return err
+				return &FieldError{Field: k, Err: err}
 			}
 
 			set(v, uint(n))
@@ -269,7 +383,7 @@ func decodeUint8(set func(reflect.Value, uint8), k string) decoder {
 		if s := g.Get(k); s != "" {
 			n, err := strconv.ParseUint(s, 10, 8)
 			if err != nil {
-				return err
+				return &FieldError{Field: k, Err: err}
 			}
 
 			set(v, uint8(n))
@@ -284,7 +398,7 @@ func decodeUint16(set func(reflect.Value, uint16), k string) decoder {
 		if s := g.Get(k); s != "" {
 			n, err := strconv.ParseUint(s, 10, 16)
 			if err != nil {
-				return err
+				return &FieldError{Field: k, Err: err}
 			}
 
 			set(v, uint16(n))
@@ -299,7 +413,7 @@ func decodeUint32(set func(reflect.Value, uint32), k string) decoder {
 		if s := g.Get(k); s != "" {
 			n, err := strconv.ParseUint(s, 10, 32)
 			if err != nil {
-				return err
+				return &FieldError{Field: k, Err: err}
 			}
 
 			set(v, uint32(n))
@@ -314,7 +428,7 @@ func decodeUint64(set func(reflect.Value, uint64), k string) decoder {
 		if s := g.Get(k); s != "" {
 			n, err := strconv.ParseUint(s, 10, 64)
 			if err != nil {
-				return err
+				return &FieldError{Field: k, Err: err}
 			}
 
 			set(v, n)
@@ -329,7 +443,7 @@ func decodeBool(set func(reflect.Value, bool), k string) decoder {
 		if s := g.Get(k); s != "" {
 			b, err := strconv.ParseBool(s)
 			if err != nil {
-				return err
+				return &FieldError{Field: k, Err: err}
 			}
 
 			set(v, b)
@@ -362,3 +476,26 @@ func decodeStrings(set func(reflect.Value, []string), k string) decoder {
 		return nil
 	}
 }
+
+// hasTag reports whether the struct or its nested structs have the tag. The
+// seen types stop recursive types.
+func hasTag(typ reflect.Type, tagKey string, seen map[reflect.Type]bool) bool {
+	if seen[typ] {
+		return false
+	}
+	seen[typ] = true
+
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		if _, ok := f.Tag.Lookup(tagKey); ok {
+			return true
+		}
+		if t, k, _ := typeKind(f.Type); k == reflect.Struct && hasTag(t, tagKey, seen) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,98 @@
+package decoder
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+var (
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	timeType          = reflect.TypeOf(time.Time{})
+	durationType      = reflect.TypeOf(time.Duration(0))
+)
+
+// Values formats the value of a header, query or path field as the strings
+// the decoders parse, the inverse of decoding it. Nil pointers, absent
+// optionals, zero times and empty strings have no values. Times are formatted
+// in UTC with the layout.
+func Values(v reflect.Value, timeLayout string) []string {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	if value, ok := optionalValue(v); ok {
+		if !value.IsValid() {
+			return nil
+		}
+		return Values(value, timeLayout)
+	}
+
+	if v.Type() == timeType {
+		t := v.Interface().(time.Time)
+		if t.IsZero() {
+			return nil
+		}
+		return []string{t.UTC().Format(timeLayout)}
+	}
+
+	if v.Type().Implements(textMarshalerType) {
+		b, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		if err != nil || len(b) == 0 {
+			return nil
+		}
+		return []string{string(b)}
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		if v.Len() == 0 {
+			return nil
+		}
+		return []string{v.String()}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if v.Type() == durationType {
+			return []string{time.Duration(v.Int()).String()}
+		}
+		return []string{strconv.FormatInt(v.Int(), 10)}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return []string{strconv.FormatUint(v.Uint(), 10)}
+	case reflect.Float32, reflect.Float64:
+		return []string{strconv.FormatFloat(v.Float(), 'f', -1, v.Type().Bits())}
+	case reflect.Bool:
+		return []string{strconv.FormatBool(v.Bool())}
+	case reflect.Slice:
+		values := []string{}
+		for i := 0; i < v.Len(); i++ {
+			values = append(values, Values(v.Index(i), timeLayout)...)
+		}
+		return values
+	}
+
+	return []string{fmt.Sprint(v.Interface())}
+}
+
+// optionalValue returns the value of an Optional from its Get method, the
+// invalid value when it is absent, and whether the value is an Optional.
+func optionalValue(v reflect.Value) (reflect.Value, bool) {
+	if !reflect.PointerTo(v.Type()).Implements(optionalType) {
+		return reflect.Value{}, false
+	}
+
+	get := v.MethodByName("Get")
+	if !get.IsValid() || get.Type().NumIn() != 0 || get.Type().NumOut() != 2 ||
+		get.Type().Out(1).Kind() != reflect.Bool {
+		return reflect.Value{}, false
+	}
+
+	out := get.Call(nil)
+	if !out[1].Bool() {
+		return reflect.Value{}, true
+	}
+	return out[0], true
+}
@@ -0,0 +1,53 @@
+package decoder
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// optional is an Optional like japi.Optional.
+type optional[T any] struct {
+	Value   T
+	Present bool
+}
+
+func (o optional[T]) Get() (T, bool) { return o.Value, o.Present }
+
+func (o *optional[T]) OptionalValue() any { return &o.Value }
+
+func (o *optional[T]) SetPresent() { o.Present = true }
+
+func TestValues(t *testing.T) {
+	at := time.Date(2024, 2, 29, 12, 0, 0, 0, time.FixedZone("", 3600))
+	n := 0
+
+	tests := []struct {
+		name  string
+		value any
+		want  []string
+	}{
+		{"string", "a", []string{"a"}},
+		{"empty string", "", nil},
+		{"int", 7, []string{"7"}},
+		{"nil pointer", (*int)(nil), nil},
+		{"zero pointer", &n, []string{"0"}},
+		{"duration", 90 * time.Minute, []string{"1h30m0s"}},
+		{"time", at, []string{"2024-02-29T11:00:00Z"}},
+		{"zero time", time.Time{}, nil},
+		{"slice", []int{1, 2}, []string{"1", "2"}},
+		{"absent optional", optional[int]{}, nil},
+		{"present zero optional", optional[int]{Present: true}, []string{"0"}},
+		{"optional slice", optional[[]string]{Value: []string{"a", "b"}, Present: true}, []string{"a", "b"}},
+		{"optional pointer", &optional[bool]{Value: true, Present: true}, []string{"true"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Values(reflect.ValueOf(tt.value), time.RFC3339)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Values(%v) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
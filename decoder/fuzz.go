@@ -0,0 +1,228 @@
+package decoder
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/goccy/go-json"
+	"github.com/julienschmidt/httprouter"
+)
+
+// FuzzValues are the seed values of each field in the built-in corpus.
+var FuzzValues = []string{
+	"", " ", "0", "-0", "1", "-1", "00012", "+1", "1.5", "1e309", "-1e309", "NaN", "Inf",
+	"9223372036854775807", "9223372036854775808", "-9223372036854775809", "18446744073709551616",
+	"t", "true", "FALSE", "yes", "2024-02-29T12:00:00Z", "2024-13-45T25:61:00Z", "1h30m", "-1ns",
+	"a,b,c", ",,", "%zz", "%00", "\x00", "\xff\xfe", "日本語", "/", "/a/b", "//", "null", "[]", "{}",
+	strings.Repeat("9", 400),
+}
+
+// FuzzBodies are the seed json bodies of the built-in corpus.
+var FuzzBodies = []string{
+	"", "null", "{}", "[]", `""`, "0", "{", `{"a":`, `{"a":1,"a":2}`, `{"":null}`, "\xff",
+	strings.Repeat("[", 1000) + strings.Repeat("]", 1000),
+}
+
+// Fuzz fuzzes the header, query and path params and the json body decoded
+// into the request type T using the header, query and path tags. The inputs
+// are url encoded keys and values like a query. It fails when decoding panics,
+// when an error is not a FieldError or Errors with the field and message of
+// the problem or when decoding the same input twice differs. The seed corpus
+// has edge case values for every tagged field.
+//
+// The decoders are fuzzed on their own as japi depends on this package. Use
+// japitest.Fuzz to fuzz the decoding of a japi.H handler and its problems.
+//
+//	func FuzzCreateUser(f *testing.F) {
+//		decoder.Fuzz[CreateUserRequest](f)
+//	}
+func Fuzz[T any](f *testing.F) {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	header, query, path := fuzzDecoder(f, t, "header"), fuzzDecoder(f, t, "query"), fuzzDecoder(f, t, "path")
+
+	headerKeys, queryKeys, pathKeys := tagNames(t, "header", nil), tagNames(t, "query", nil), tagNames(t, "path", nil)
+	for i, v := range FuzzValues {
+		body := FuzzBodies[i%len(FuzzBodies)]
+		f.Add(seed(headerKeys, v), seed(queryKeys, v), seed(pathKeys, v), []byte(body))
+	}
+
+	f.Fuzz(func(ft *testing.T, headerInput, queryInput, pathInput string, body []byte) {
+		decode := func() (*T, []error) {
+			req := new(T)
+			var errs []error
+
+			if header != nil {
+				h := http.Header{}
+				for k, vs := range parseInput(headerInput) {
+					for _, v := range vs {
+						h.Add(k, v)
+					}
+				}
+				errs = append(errs, header.Decode(h, req))
+			}
+			if query != nil {
+				errs = append(errs, query.Decode(MapGetter(parseInput(queryInput)), req))
+			}
+			if path != nil {
+				var params httprouter.Params
+				for k, vs := range parseInput(pathInput) {
+					for _, v := range vs {
+						params = append(params, httprouter.Param{Key: k, Value: v})
+					}
+				}
+				errs = append(errs, path.Decode(ParamsGetter(params), req))
+			}
+			if len(body) > 0 {
+				_ = json.Unmarshal(body, req) // errors are bad request problems
+			}
+			return req, errs
+		}
+
+		first, errs := decode()
+		for _, err := range errs {
+			checkFuzzError(ft, err)
+		}
+
+		second, again := decode()
+		if !sameValue(reflect.ValueOf(first), reflect.ValueOf(second)) ||
+			!reflect.DeepEqual(errorStrings(errs), errorStrings(again)) {
+			ft.Errorf("decoder: decoding the same input twice differs: %+v %v and %+v %v", first, errs, second, again)
+		}
+	})
+}
+
+func fuzzDecoder(f *testing.F, t reflect.Type, tag string) *CachedDecoder {
+	if t.Kind() != reflect.Struct || !hasTag(t, tag, map[reflect.Type]bool{}) {
+		return nil
+	}
+	dec, err := NewCachedDecoder(reflect.Zero(t).Interface(), tag)
+	if err != nil {
+		f.Fatalf("decoder: %s %s: %v", t, tag, err)
+	}
+	return dec
+}
+
+// checkFuzzError fails unless the error can be served as a validation problem.
+func checkFuzzError(t *testing.T, err error) {
+	if err == nil {
+		return
+	}
+
+	var fes Errors
+	var fe *FieldError
+	switch {
+	case errors.As(err, &fes):
+		for _, fe := range fes {
+			checkFuzzError(t, fe)
+		}
+	case errors.As(err, &fe):
+		if fe.Field == "" || fe.Message() == "" {
+			t.Errorf("decoder: field error without a field or message: %v", err)
+		}
+	default:
+		t.Errorf("decoder: error is not a field error: %v", err)
+	}
+}
+
+// sameValue reports whether the decoded values are deeply equal with NaN equal
+// to NaN, unlike reflect.DeepEqual.
+func sameValue(a, b reflect.Value) bool {
+	switch a.Kind() {
+	case reflect.Float32, reflect.Float64:
+		x, y := a.Float(), b.Float()
+		return x == y || x != x && y != y
+	case reflect.Pointer, reflect.Interface:
+		if a.IsNil() || b.IsNil() {
+			return a.IsNil() == b.IsNil()
+		}
+		return sameValue(a.Elem(), b.Elem())
+	case reflect.Slice, reflect.Array:
+		if a.Len() != b.Len() {
+			return false
+		}
+		for i := 0; i < a.Len(); i++ {
+			if !sameValue(a.Index(i), b.Index(i)) {
+				return false
+			}
+		}
+		return true
+	case reflect.Struct:
+		for i := 0; i < a.NumField(); i++ {
+			if !sameValue(a.Field(i), b.Field(i)) {
+				return false
+			}
+		}
+		return true
+	case reflect.String:
+		return a.String() == b.String()
+	case reflect.Bool:
+		return a.Bool() == b.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return a.Int() == b.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return a.Uint() == b.Uint()
+	}
+	return !a.CanInterface() || reflect.DeepEqual(a.Interface(), b.Interface())
+}
+
+func errorStrings(errs []error) []string {
+	s := make([]string, len(errs))
+	for i, err := range errs {
+		if err != nil {
+			s[i] = err.Error()
+		}
+	}
+	return s
+}
+
+// parseInput parses the url encoded input ignoring the invalid pairs.
+func parseInput(input string) map[string][]string {
+	values, _ := url.ParseQuery(input)
+	return values
+}
+
+// seed encodes the value for each key.
+func seed(keys []string, v string) string {
+	values := url.Values{}
+	for _, k := range keys {
+		values.Add(k, v)
+	}
+	return values.Encode()
+}
+
+// tagNames returns the names of the tagged fields of the struct and its
+// nested structs. The seen types stop recursive types.
+func tagNames(t reflect.Type, tag string, seen map[reflect.Type]bool) []string {
+	if t.Kind() != reflect.Struct || seen[t] {
+		return nil
+	}
+	if seen == nil {
+		seen = map[reflect.Type]bool{}
+	}
+	seen[t] = true
+
+	var names []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		if name, ok := f.Tag.Lookup(tag); ok {
+			name, _, _ = strings.Cut(name, ",")
+			names = append(names, name)
+			continue
+		}
+		ft := f.Type
+		for ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if ft.Kind() == reflect.Struct {
+			names = append(names, tagNames(ft, tag, seen)...)
+		}
+	}
+	return names
+}
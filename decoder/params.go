@@ -1,6 +1,11 @@
 package decoder
 
-import "github.com/julienschmidt/httprouter"
+import (
+	"net/url"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+)
 
 type ParamsDecoder struct {
 	dec *CachedDecoder
@@ -19,6 +24,15 @@ func (d *ParamsDecoder) Decode(data []httprouter.Param, v interface{}) error {
 	return d.dec.Decode(ParamsGetter(data), v)
 }
 
+// DecodeURL decodes the params matched for the URL. Catch-all params decode
+// into slices as the unescaped segments of the raw path so escaped slashes
+// stay within their segment.
+func (d *ParamsDecoder) DecodeURL(u *url.URL, data []httprouter.Param, v interface{}) error {
+	return d.dec.Decode(urlParamsGetter{ParamsGetter: data, url: u}, v)
+}
+
+// ParamsGetter gets the route params. Catch-all values start with a slash and
+// are split into the path segments for slices.
 type ParamsGetter []httprouter.Param
 
 func (ps ParamsGetter) Get(key string) string {
@@ -34,9 +48,65 @@ func (ps ParamsGetter) Get(key string) string {
 func (ps ParamsGetter) Values(key string) []string {
 	for i := range ps {
 		if ps[i].Key == key {
+			if strings.HasPrefix(ps[i].Value, "/") {
+				return segments(ps[i].Value)
+			}
 			return []string{ps[i].Value}
 		}
 	}
 
 	return nil
 }
+
+type urlParamsGetter struct {
+	ParamsGetter
+	url *url.URL
+}
+
+func (g urlParamsGetter) Values(key string) []string {
+	v := g.Get(key)
+	if g.url.RawPath == "" || !strings.HasPrefix(v, "/") {
+		return g.ParamsGetter.Values(key)
+	}
+
+	// the catch-all is the escaped path suffix that unescapes to its value, when
+	// there is none the router split an escaped slash before it so keep its split
+	escaped := g.url.EscapedPath()
+	for i := strings.IndexByte(escaped, '/'); i >= 0; i = nextSlash(escaped, i) {
+		if s, err := url.PathUnescape(escaped[i:]); err != nil || s != v {
+			continue
+		}
+
+		values := []string{}
+		for _, seg := range strings.Split(escaped[i:], "/") {
+			if seg == "" {
+				continue
+			}
+			if s, err := url.PathUnescape(seg); err == nil {
+				seg = s
+			}
+			values = append(values, seg)
+		}
+		return values
+	}
+
+	return g.ParamsGetter.Values(key)
+}
+
+func nextSlash(s string, i int) int {
+	j := strings.IndexByte(s[i+1:], '/')
+	if j < 0 {
+		return -1
+	}
+	return i + 1 + j
+}
+
+func segments(path string) []string {
+	values := []string{}
+	for _, seg := range strings.Split(path, "/") {
+		if seg != "" {
+			values = append(values, seg)
+		}
+	}
+	return values
+}
@@ -0,0 +1,81 @@
+package decoder
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+func TestParamsDecoderDecodeURL(t *testing.T) {
+	type request struct {
+		ID   string   `path:"id"`
+		Path []string `path:"path"`
+	}
+
+	tests := []struct {
+		name   string
+		url    string
+		params httprouter.Params
+		want   request
+	}{
+		{
+			name:   "segments",
+			url:    "/files/a/b/c",
+			params: httprouter.Params{{Key: "path", Value: "/a/b/c"}},
+			want:   request{Path: []string{"a", "b", "c"}},
+		},
+		{
+			name:   "escaped slash in catch-all",
+			url:    "/files/a%2Fb/c",
+			params: httprouter.Params{{Key: "path", Value: "/a/b/c"}},
+			want:   request{Path: []string{"a/b", "c"}},
+		},
+		{
+			name:   "nested route",
+			url:    "/users/7/files/docs/a%2Fb.txt",
+			params: httprouter.Params{{Key: "id", Value: "7"}, {Key: "path", Value: "/docs/a/b.txt"}},
+			want:   request{ID: "7", Path: []string{"docs", "a/b.txt"}},
+		},
+		{
+			name:   "escaped slash before catch-all",
+			url:    "/files/a%2Fb/c%2Fd",
+			params: httprouter.Params{{Key: "id", Value: "a"}, {Key: "path", Value: "/b/c/d"}},
+			want:   request{ID: "a", Path: []string{"b", "c", "d"}},
+		},
+		{
+			name:   "empty segments",
+			url:    "/files/a//b%2F/",
+			params: httprouter.Params{{Key: "path", Value: "/a//b//"}},
+			want:   request{Path: []string{"a", "b/"}},
+		},
+		{
+			name:   "root catch-all",
+			url:    "/a%2Fb/c",
+			params: httprouter.Params{{Key: "path", Value: "/a/b/c"}},
+			want:   request{Path: []string{"a/b", "c"}},
+		},
+	}
+
+	dec, err := NewParamsDecoder(request{}, "path")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := url.Parse(tt.url)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got request
+			if err := dec.DecodeURL(u, tt.params, &got); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package decoder
+
+import (
+	"encoding"
+	"reflect"
+	"time"
+)
+
+// parser parses the string into a value of the registered type.
+type parser func(string) (reflect.Value, error)
+
+var (
+	parsers             = map[reflect.Type]parser{}
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+func init() {
+	RegisterParser(func(s string) (time.Time, error) {
+		return time.Parse(time.RFC3339, s)
+	})
+	RegisterParser(time.ParseDuration)
+}
+
+// RegisterParser registers a func to parse header, query & path values into
+// T. Parsers must be registered before the handlers are created.
+func RegisterParser[T any](fn func(string) (T, error)) {
+	parsers[reflect.TypeOf((*T)(nil)).Elem()] = func(s string) (reflect.Value, error) {
+		v, err := fn(s)
+		return reflect.ValueOf(&v).Elem(), err
+	}
+}
+
+// parserFor returns the registered parser or a parser using
+// encoding.TextUnmarshaler, returning nil for other types.
+func parserFor(t reflect.Type) parser {
+	if p, ok := parsers[t]; ok {
+		return p
+	}
+
+	if reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		return func(s string) (reflect.Value, error) {
+			v := reflect.New(t)
+			err := v.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+			return v.Elem(), err
+		}
+	}
+
+	return nil
+}
+
+func setValue(fi field) func(reflect.Value, reflect.Value) {
+	return func(v reflect.Value, d reflect.Value) {
+		f := v.Field(fi.index)
+		if fi.ptr {
+			if f.IsNil() {
+				f.Set(reflect.New(f.Type().Elem()))
+			}
+			f = f.Elem()
+		}
+
+		if fi.optional {
+			o := f.Addr().Interface().(Optional)
+			reflect.ValueOf(o.OptionalValue()).Elem().Set(d)
+			o.SetPresent()
+			return
+		}
+
+		f.Set(d)
+	}
+}
+
+func decodeValue(set func(reflect.Value, reflect.Value), k string, parse parser) decoder {
+	return func(v reflect.Value, g Getter) error {
+		if s := g.Get(k); s != "" {
+			d, err := parse(s)
+			if err != nil {
+				return &FieldError{Field: k, Err: err}
+			}
+
+			set(v, d)
+		}
+
+		return nil
+	}
+}
+
+func decodeValues(set func(reflect.Value, reflect.Value), k string, t reflect.Type, parse parser) decoder {
+	return func(v reflect.Value, g Getter) error {
+		if ss := g.Values(k); ss != nil {
+			d := reflect.MakeSlice(t, 0, len(ss))
+			for _, s := range ss {
+				e, err := parse(s)
+				if err != nil {
+					return &FieldError{Field: k, Err: err}
+				}
+				d = reflect.Append(d, e)
+			}
+
+			set(v, d)
+		}
+
+		return nil
+	}
+}
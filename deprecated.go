@@ -0,0 +1,71 @@
+package japi
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// deprecation is the sunset and documentation of a deprecated route.
+type deprecation struct {
+	sunset time.Time
+	docURL string
+}
+
+// Deprecated marks the route deprecated. The responses have the Deprecation
+// header, the Sunset header unless the sunset time is zero and a Link to the
+// doc URL unless it is empty. Each request is logged with
+// Config.DeprecationLogFunc and the OpenAPI operation is deprecated.
+func Deprecated(sunset time.Time, docURL string) RouteOption {
+	return func(rt *Route) {
+		rt.deprecation = &deprecation{sunset: sunset, docURL: docURL}
+	}
+}
+
+// deprecated wraps the handle to send the deprecation headers and log the
+// request.
+func deprecated(ref *configRef, route string, d *deprecation, next httprouter.Handle) httprouter.Handle {
+	sunset := ""
+	if !d.sunset.IsZero() {
+		sunset = d.sunset.UTC().Format(http.TimeFormat)
+	}
+
+	return func(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+		h := w.Header()
+		h.Set("Deprecation", "true")
+		if sunset != "" {
+			h.Set("Sunset", sunset)
+		}
+		if d.docURL != "" {
+			h.Add("Link", "<"+d.docURL+`>; rel="deprecation"`)
+		}
+
+		c := ref.Load().forTenant(r.Context())
+		if c.DeprecationLogFunc != nil {
+			c.DeprecationLogFunc(r.Context(), route, d.sunset)
+		} else if c.Logger != nil {
+			c.logDeprecation(r.Context(), route, d.sunset)
+		}
+
+		next(w, r, p)
+	}
+}
+
+// logDeprecation logs the request of a deprecated route when there is no
+// DeprecationLogFunc.
+func (c *Config) logDeprecation(ctx context.Context, route string, sunset time.Time) {
+	attrs := []slog.Attr{slog.String("route", route)}
+	if !sunset.IsZero() {
+		attrs = append(attrs, slog.Time("sunset", sunset))
+	}
+	if ip := ClientIP(ctx); ip != "" {
+		attrs = append(attrs, slog.String("ip", ip))
+	}
+	if tenant := Tenant(ctx); tenant != "" {
+		attrs = append(attrs, slog.String("tenant", tenant))
+	}
+	c.Logger.LogAttrs(ctx, slog.LevelWarn, "deprecated route", attrs...)
+}
@@ -0,0 +1,163 @@
+package japi
+
+import (
+	"crypto/subtle"
+	"html/template"
+	"net/http"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/jarrettv/go-japi/problem"
+)
+
+// DocsOption configures the docs endpoints.
+type DocsOption func(*docsConfig)
+
+type docsConfig struct {
+	title     string
+	version   string
+	redoc     bool
+	assets    string
+	integrity map[string]string
+	spec      func() ([]byte, error)
+	user      string
+	password  string
+}
+
+// The default assets are pinned to exact versions so the page does not load
+// scripts that were published after it was reviewed.
+const (
+	swaggerUIAssets = "https://cdn.jsdelivr.net/npm/swagger-ui-dist@5.17.14"
+	redocAssets     = "https://cdn.jsdelivr.net/npm/redoc@2.1.5/bundles"
+)
+
+// DocsInfo sets the title and version of the generated OpenAPI document.
+func DocsInfo(title, version string) DocsOption {
+	return func(c *docsConfig) {
+		c.title, c.version = title, version
+	}
+}
+
+// DocsRedoc serves Redoc instead of Swagger UI.
+func DocsRedoc() DocsOption {
+	return func(c *docsConfig) {
+		c.redoc = true
+	}
+}
+
+// DocsAssets loads the swagger-ui-bundle.js and swagger-ui.css or the
+// redoc.standalone.js files from the base URL instead of jsDelivr.
+func DocsAssets(baseURL string) DocsOption {
+	return func(c *docsConfig) {
+		c.assets = strings.TrimSuffix(baseURL, "/")
+	}
+}
+
+// DocsIntegrity sets the subresource integrity hashes of the asset files by
+// file name e.g. sha384-... for swagger-ui-bundle.js, so the browser refuses
+// assets that were changed.
+func DocsIntegrity(hashes map[string]string) DocsOption {
+	return func(c *docsConfig) {
+		c.integrity = hashes
+	}
+}
+
+// DocsSpec serves the loaded OpenAPI document instead of the generated one.
+func DocsSpec(load func() ([]byte, error)) DocsOption {
+	return func(c *docsConfig) {
+		c.spec = load
+	}
+}
+
+// DocsBasicAuth protects the docs endpoints with the user and password.
+func DocsBasicAuth(user, password string) DocsOption {
+	return func(c *docsConfig) {
+		c.user, c.password = user, password
+	}
+}
+
+var docsPage = template.Must(template.New("docs").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+{{- if .Redoc}}
+</head>
+<body>
+<redoc spec-url="{{.Spec}}"></redoc>
+<script src="{{.Assets}}/redoc.standalone.js"{{with index .Integrity "redoc.standalone.js"}} integrity="{{.}}" crossorigin="anonymous"{{end}}></script>
+{{- else}}
+<link rel="stylesheet" href="{{.Assets}}/swagger-ui.css"{{with index .Integrity "swagger-ui.css"}} integrity="{{.}}" crossorigin="anonymous"{{end}}>
+</head>
+<body>
+<div id="swagger-ui"></div>
+<script src="{{.Assets}}/swagger-ui-bundle.js"{{with index .Integrity "swagger-ui-bundle.js"}} integrity="{{.}}" crossorigin="anonymous"{{end}}></script>
+<script>SwaggerUIBundle({url: {{.Spec}}, dom_id: "#swagger-ui"});</script>
+{{- end}}
+</body>
+</html>
+`))
+
+// Docs serves the Swagger UI page at path and the OpenAPI document of the
+// routes at path/openapi.json. The docs endpoints are not in the document.
+func (r *API) Docs(path string, opts ...DocsOption) {
+	c := &docsConfig{title: "API", version: "1.0.0"}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.assets == "" {
+		c.assets = swaggerUIAssets
+		if c.redoc {
+			c.assets = redocAssets
+		}
+	}
+
+	path = strings.TrimSuffix(path, "/")
+	pagePath := path
+	if pagePath == "" {
+		pagePath = "/"
+	}
+	guard := func(h http.Handler) httprouter.Handle {
+		if c.user == "" {
+			return wrapHandler(h)
+		}
+		return wrapHandler(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			user, password, ok := req.BasicAuth()
+			if !ok || subtle.ConstantTimeCompare([]byte(user), []byte(c.user)) != 1 ||
+				subtle.ConstantTimeCompare([]byte(password), []byte(c.password)) != 1 {
+				r.config.Load().serveProblem(w, req, problem.Status(http.StatusUnauthorized).
+					WithAuthenticate(`Basic realm="docs"`))
+				return
+			}
+			h.ServeHTTP(w, req)
+		}))
+	}
+
+	spec := c.spec
+	if spec == nil {
+		spec = func() ([]byte, error) {
+			return r.OpenAPI(c.title, c.version)
+		}
+	}
+
+	// the relative url works when the api is mounted
+	specURL := "openapi.json"
+	if path != "" {
+		specURL = path[strings.LastIndex(path, "/")+1:] + "/openapi.json"
+	}
+	page := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_ = docsPage.Execute(w, map[string]any{
+			"Title":     c.title,
+			"Redoc":     c.redoc,
+			"Assets":    c.assets,
+			"Integrity": c.integrity,
+			"Spec":      specURL,
+		})
+	})
+
+	r.register(http.MethodGet, pagePath, guard(page))
+	r.register(http.MethodGet, path+"/openapi.json", guard(LoadRawJson(spec)))
+}
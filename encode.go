@@ -0,0 +1,270 @@
+package japi
+
+import (
+	"bytes"
+	stdjson "encoding/json"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"unicode"
+
+	"github.com/goccy/go-json"
+)
+
+// FieldCase transforms the object keys of the encoded json.
+type FieldCase int
+
+const (
+	// KeepCase leaves the keys as encoded.
+	KeepCase FieldCase = iota
+	// CamelCase transforms the keys to camelCase.
+	CamelCase
+	// SnakeCase transforms the keys to snake_case.
+	SnakeCase
+)
+
+// EncodeOptions configures how responses are encoded to json.
+type EncodeOptions struct {
+	// PrettyQuery is the query param to pretty print the json e.g. ?pretty=1
+	PrettyQuery string
+	// Indent will always pretty print the json with the indent.
+	Indent string
+	// OmitEmpty will leave empty values out of the objects.
+	OmitEmpty bool
+	// DisableHTMLEscape will not escape <, > and & in strings.
+	DisableHTMLEscape bool
+	// FieldCase transforms the object keys.
+	FieldCase FieldCase
+
+	fields  fieldSet                    // selected with WithSparseFields
+	marshal func(v any) ([]byte, error) // set from Config.JSONMarshal
+}
+
+// WithEncodeOptions overrides how the route responses are encoded.
+func WithEncodeOptions(opts EncodeOptions) RouteOption {
+	return func(rt *Route) {
+		rt.encode = &opts
+	}
+}
+
+// encodeJSON will encode the value to the response using the options.
+func encodeJSON(w io.Writer, r *http.Request, v any, opts EncodeOptions) error {
+	indent := opts.Indent
+	if opts.PrettyQuery != "" && indent == "" && r.URL.RawQuery != "" {
+		if isTrue(r.URL.Query().Get(opts.PrettyQuery)) {
+			indent = "  "
+		}
+	}
+
+	marshal := opts.marshal
+	if codec := jsonCodecFor(reflect.TypeOf(v)); codec != nil {
+		marshal = codec.Marshal
+	}
+	rewrite := opts.OmitEmpty || opts.FieldCase != KeepCase || opts.fields != nil
+
+	if marshal == nil && !rewrite {
+		enc := json.NewEncoder(w)
+		enc.SetEscapeHTML(!opts.DisableHTMLEscape)
+		if indent != "" {
+			enc.SetIndent("", indent)
+		}
+		return enc.Encode(v)
+	}
+
+	var data []byte
+	var err error
+	if marshal != nil {
+		data, err = marshal(v)
+	} else {
+		var opt []json.EncodeOptionFunc
+		if opts.DisableHTMLEscape {
+			opt = append(opt, json.DisableHTMLEscape())
+		}
+		data, err = json.MarshalWithOption(v, opt...)
+	}
+	if err != nil {
+		return err
+	}
+
+	buf := *bytes.NewBuffer(data)
+	if rewrite {
+		dec := stdjson.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+
+		buf = bytes.Buffer{}
+		if _, err := rewriteJSON(dec, &buf, opts); err != nil {
+			return err
+		}
+	}
+
+	if indent != "" {
+		var out bytes.Buffer
+		if err := stdjson.Indent(&out, buf.Bytes(), "", indent); err != nil {
+			return err
+		}
+		buf = out
+	}
+
+	buf.WriteByte('\n')
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+func isTrue(s string) bool {
+	switch strings.ToLower(s) {
+	case "1", "t", "true", "yes", "y":
+		return true
+	}
+	return false
+}
+
+// rewriteJSON copies the next value from the decoder transforming the keys,
+// omitting empty values and keeping the selected fields. It returns whether the
+// value was empty.
+func rewriteJSON(dec *stdjson.Decoder, buf *bytes.Buffer, opts EncodeOptions) (bool, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return false, err
+	}
+
+	switch t := tok.(type) {
+	case stdjson.Delim:
+		if t == '[' {
+			buf.WriteByte('[')
+			n := 0
+			for dec.More() {
+				if n > 0 {
+					buf.WriteByte(',')
+				}
+				if _, err := rewriteJSON(dec, buf, opts); err != nil {
+					return false, err
+				}
+				n++
+			}
+			if _, err := dec.Token(); err != nil {
+				return false, err
+			}
+			buf.WriteByte(']')
+			return n == 0, nil
+		}
+
+		buf.WriteByte('{')
+		n := 0
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return false, err
+			}
+
+			name := transformKey(key.(string), opts.FieldCase)
+			child := opts
+			selected := true
+			if opts.fields != nil {
+				child.fields, selected = opts.fields[name]
+			}
+
+			var val bytes.Buffer
+			empty, err := rewriteJSON(dec, &val, child)
+			if err != nil {
+				return false, err
+			}
+			if !selected || empty && opts.OmitEmpty {
+				continue
+			}
+
+			if n > 0 {
+				buf.WriteByte(',')
+			}
+			writeString(buf, name, opts)
+			buf.WriteByte(':')
+			buf.Write(val.Bytes())
+			n++
+		}
+		if _, err := dec.Token(); err != nil {
+			return false, err
+		}
+		buf.WriteByte('}')
+		return n == 0, nil
+	case string:
+		writeString(buf, t, opts)
+		return t == "", nil
+	case stdjson.Number:
+		buf.WriteString(t.String())
+		return strings.Trim(t.String(), "0.-") == "", nil
+	case bool:
+		if t {
+			buf.WriteString("true")
+		} else {
+			buf.WriteString("false")
+		}
+		return !t, nil
+	default:
+		buf.WriteString("null")
+		return true, nil
+	}
+}
+
+func writeString(buf *bytes.Buffer, s string, opts EncodeOptions) {
+	enc := stdjson.NewEncoder(buf)
+	enc.SetEscapeHTML(!opts.DisableHTMLEscape)
+	_ = enc.Encode(s)
+	buf.Truncate(buf.Len() - 1) // remove the newline
+}
+
+func transformKey(key string, fc FieldCase) string {
+	switch fc {
+	case CamelCase:
+		return toCamelCase(key)
+	case SnakeCase:
+		return toSnakeCase(key)
+	}
+	return key
+}
+
+func toSnakeCase(s string) string {
+	rs := []rune(s)
+	var b strings.Builder
+	for i, r := range rs {
+		if r == '-' || r == ' ' {
+			b.WriteByte('_')
+			continue
+		}
+		if unicode.IsUpper(r) {
+			if i > 0 && rs[i-1] != '_' && (unicode.IsLower(rs[i-1]) || unicode.IsDigit(rs[i-1]) ||
+				(i+1 < len(rs) && unicode.IsUpper(rs[i-1]) && unicode.IsLower(rs[i+1]))) {
+				b.WriteByte('_')
+			}
+			b.WriteRune(unicode.ToLower(r))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func toCamelCase(s string) string {
+	if strings.ContainsAny(s, "_- ") {
+		parts := strings.FieldsFunc(s, func(r rune) bool { return r == '_' || r == '-' || r == ' ' })
+		for i, p := range parts {
+			if i == 0 {
+				parts[i] = strings.ToLower(p)
+			} else {
+				rs := []rune(strings.ToLower(p))
+				rs[0] = unicode.ToUpper(rs[0])
+				parts[i] = string(rs)
+			}
+		}
+		return strings.Join(parts, "")
+	}
+
+	// lower the leading upper case run e.g. UserID to userID, HTTPServer to httpServer
+	rs := []rune(s)
+	for i := 0; i < len(rs) && unicode.IsUpper(rs[i]); i++ {
+		if i > 0 && i+1 < len(rs) && unicode.IsLower(rs[i+1]) {
+			break
+		}
+		rs[i] = unicode.ToLower(rs[i])
+	}
+	return string(rs)
+}
@@ -0,0 +1,77 @@
+package japi
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/jarrettv/go-japi/problem"
+)
+
+// WithSparseFields lets the clients select the fields of the json response
+// with the comma separated ?fields=id,name query. Dotted fields select nested
+// fields e.g. author.name and the fields of each array item are selected. Only
+// the allowed fields and their nested fields can be selected, every field
+// without an allow-list.
+func WithSparseFields(allowed ...string) RouteOption {
+	return func(rt *Route) {
+		rt.fields = &sparseFields{allowed: allowed}
+	}
+}
+
+type sparseFields struct {
+	allowed []string
+}
+
+// fieldSet is the selection of the object keys, a nil selection is the whole
+// value.
+type fieldSet map[string]fieldSet
+
+// parse returns the selected fields or nil when none are selected adding the
+// errors of fields that are not allowed.
+func (s *sparseFields) parse(query url.Values, errs problem.Errors) fieldSet {
+	list := splitList(query.Get("fields"))
+	if len(list) == 0 {
+		return nil
+	}
+
+	fs := fieldSet{}
+	for _, field := range list {
+		if !s.allows(field) {
+			errs.Add("fields", field+" is not a field")
+			continue
+		}
+		fs.add(field)
+	}
+	return fs
+}
+
+func (s *sparseFields) allows(field string) bool {
+	if len(s.allowed) == 0 {
+		return true
+	}
+	for _, a := range s.allowed {
+		if field == a || strings.HasPrefix(field, a+".") {
+			return true
+		}
+	}
+	return false
+}
+
+func (fs fieldSet) add(field string) {
+	parts := strings.Split(field, ".")
+	for i, part := range parts {
+		if i == len(parts)-1 {
+			fs[part] = nil
+			return
+		}
+		child, ok := fs[part]
+		if ok && child == nil {
+			return // the whole value is already selected
+		}
+		if !ok {
+			child = fieldSet{}
+			fs[part] = child
+		}
+		fs = child
+	}
+}
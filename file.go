@@ -0,0 +1,114 @@
+package japi
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"time"
+)
+
+// File is a response that streams the content instead of encoding json.
+// Range requests are supported when the Reader is an io.ReadSeeker.
+type File struct {
+	// Name is the file name for the Content-Disposition header.
+	Name string
+	// ContentType defaults to the type of the Name extension.
+	ContentType string
+	// Reader is the content which is closed if it is an io.Closer.
+	Reader io.Reader
+	// Size is the Content-Length when the Reader is not an io.ReadSeeker.
+	Size int64
+	// ModTime is used for the Last-Modified header when set.
+	ModTime time.Time
+	// Inline will display the file in the browser instead of downloading.
+	Inline bool
+	// Headers are sent with the file e.g. Cache-Control or ETag.
+	Headers http.Header
+}
+
+// Header implements Headerer.
+func (f File) Header() http.Header {
+	return f.Headers
+}
+
+// serveFile will serve the file and []byte responses, returning false
+// for other responses. The status of the route replaces 200 OK in which case
+// range requests are not supported.
+func serveFile(w http.ResponseWriter, r *http.Request, res any, status int) bool {
+	var f *File
+	switch v := res.(type) {
+	case File:
+		f = &v
+	case *File:
+		f = v
+	case []byte:
+		f = &File{ContentType: "application/octet-stream", Reader: bytes.NewReader(v), Inline: true}
+	default:
+		return false
+	}
+
+	if f == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return true
+	}
+
+	if c, ok := f.Reader.(io.Closer); ok {
+		defer c.Close()
+	}
+
+	header := w.Header()
+	for k, v := range f.Headers {
+		header[k] = v
+	}
+
+	if f.Reader == nil || status == http.StatusNoContent {
+		w.WriteHeader(http.StatusNoContent)
+		return true
+	}
+
+	if f.ContentType != "" {
+		header.Set("Content-Type", f.ContentType)
+	}
+
+	disposition := "attachment"
+	if f.Inline {
+		disposition = "inline"
+	}
+	if f.Name != "" {
+		header.Set("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": f.Name}))
+	} else if !f.Inline {
+		header.Set("Content-Disposition", disposition)
+	}
+
+	if rs, ok := f.Reader.(io.ReadSeeker); ok && (status == 0 || status == http.StatusOK) {
+		http.ServeContent(w, r, f.Name, f.ModTime, rs)
+		return true
+	}
+
+	if f.ContentType == "" {
+		if ct := mime.TypeByExtension(path.Ext(f.Name)); ct != "" {
+			header.Set("Content-Type", ct)
+		} else {
+			header.Set("Content-Type", "application/octet-stream")
+		}
+	}
+	if f.Size > 0 {
+		header.Set("Content-Length", strconv.FormatInt(f.Size, 10))
+	}
+	if !f.ModTime.IsZero() {
+		header.Set("Last-Modified", f.ModTime.UTC().Format(http.TimeFormat))
+	}
+
+	if status == 0 {
+		status = http.StatusOK
+	}
+	w.WriteHeader(status)
+	if r.Method != http.MethodHead {
+		_, _ = io.Copy(w, f.Reader)
+	}
+
+	return true
+}
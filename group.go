@@ -8,38 +8,47 @@ import (
 type group struct {
 	r      *API
 	prefix string
+	opts   []RouteOption
 }
 
-func (g *group) Get(path string, handle http.Handler) {
-	g.Handle(http.MethodGet, path, handle)
+func (g *group) Get(path string, handle http.Handler, opts ...RouteOption) {
+	g.Handle(http.MethodGet, path, handle, opts...)
 }
 
-func (g *group) Post(path string, handle http.Handler) {
-	g.Handle(http.MethodPost, path, handle)
+func (g *group) Post(path string, handle http.Handler, opts ...RouteOption) {
+	g.Handle(http.MethodPost, path, handle, opts...)
 }
 
-func (g *group) Put(path string, handle http.Handler) {
-	g.Handle(http.MethodPut, path, handle)
+func (g *group) Put(path string, handle http.Handler, opts ...RouteOption) {
+	g.Handle(http.MethodPut, path, handle, opts...)
 }
 
-func (g *group) Patch(path string, handle http.Handler) {
-	g.Handle(http.MethodPatch, path, handle)
+func (g *group) Patch(path string, handle http.Handler, opts ...RouteOption) {
+	g.Handle(http.MethodPatch, path, handle, opts...)
 }
 
-func (g *group) Delete(path string, handle http.Handler) {
-	g.Handle(http.MethodDelete, path, handle)
+func (g *group) Delete(path string, handle http.Handler, opts ...RouteOption) {
+	g.Handle(http.MethodDelete, path, handle, opts...)
 }
 
-func (g *group) Handle(method, path string, handle http.Handler) {
-	g.r.Handle(method, g.prefix+path, handle)
+func (g *group) Handle(method, path string, handle http.Handler, opts ...RouteOption) {
+	g.r.Handle(method, g.prefix+path, handle, withGroupOptions(g.opts, opts)...)
 }
 
-func (g *group) HandleFunc(method, path string, handle http.HandlerFunc) {
-	g.Handle(method, path, handle)
+func (g *group) HandleFunc(method, path string, handle http.HandlerFunc, opts ...RouteOption) {
+	g.Handle(method, path, handle, opts...)
 }
 
-func (g *group) Group(path string) Router {
-	return &group{prefix: g.prefix + path, r: g.r}
+func (g *group) Group(path string, opts ...RouteOption) Router {
+	return &group{prefix: g.prefix + path, r: g.r, opts: withGroupOptions(g.opts, opts)}
+}
+
+func (g *group) Mount(path string, child *API) {
+	g.r.Mount(g.prefix+path, child)
+}
+
+func (g *group) Version(version string) Router {
+	return &versioned{r: g.r, prefix: g.prefix, version: version, opts: g.opts}
 }
 
 func (g *group) Use(mw ...Middleware) {
@@ -61,3 +70,11 @@ func (g *group) Use(mw ...Middleware) {
 		})
 	})
 }
+
+// withGroupOptions returns the group options followed by the route options.
+func withGroupOptions(group, opts []RouteOption) []RouteOption {
+	if len(group) == 0 {
+		return opts
+	}
+	return append(append([]RouteOption(nil), group...), opts...)
+}
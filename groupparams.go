@@ -0,0 +1,73 @@
+package japi
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/jarrettv/go-japi/decoder"
+	"github.com/jarrettv/go-japi/problem"
+)
+
+// paramsHook decodes and checks the path params before the handler runs.
+type paramsHook func(r *http.Request, p httprouter.Params) (*http.Request, error)
+
+type paramsKey[T any] struct{}
+
+// WithParams decodes the path params tagged with path into T, runs the check
+// and stores T in the context for the handler. Pass it to Group so the group
+// handlers don't need to declare and check the shared params themselves.
+//
+//	tenants := r.Group("/tenants/:tenant", japi.WithParams(checkTenant))
+func WithParams[T any](check func(ctx context.Context, params *T) error) RouteOption {
+	var t T
+	dec, err := decoder.NewParamsDecoder(t, pathTag)
+	if err != nil {
+		panic("japi: WithParams: " + err.Error())
+	}
+
+	hook := func(r *http.Request, p httprouter.Params) (*http.Request, error) {
+		params := new(T)
+		if e := dec.DecodeURL(r.URL, p, params); e != nil {
+			errs := problem.Errors{}
+			addDecodeError(errs, pathTag, e)
+			return nil, problem.ValidationErrors(errs)
+		}
+		if check != nil {
+			if e := check(r.Context(), params); e != nil {
+				return nil, e
+			}
+		}
+		return r.WithContext(context.WithValue(r.Context(), paramsKey[T]{}, *params)), nil
+	}
+
+	return func(rt *Route) {
+		rt.params = append(rt.params, hook)
+	}
+}
+
+// ParamsFrom returns the params of the WithParams route option.
+func ParamsFrom[T any](ctx context.Context) (T, bool) {
+	params, ok := ctx.Value(paramsKey[T]{}).(T)
+	return params, ok
+}
+
+// withParams wraps the handle to run the params hooks.
+func withParams(ref *configRef, hooks []paramsHook, next httprouter.Handle) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+		for _, hook := range hooks {
+			req, e := hook(r, p)
+			if e != nil {
+				pb, ok := asProblem(e)
+				if !ok {
+					pb = problem.Unexpected(e)
+				}
+				ref.Load().serveProblem(w, r, pb)
+				return
+			}
+			r = req
+		}
+		next(w, r, p)
+	}
+}
@@ -2,9 +2,12 @@ package japi
 
 import (
 	"context"
+	"errors"
+	"io"
 	"net/http"
-
-	"github.com/goccy/go-json"
+	"reflect"
+	"sync"
+	"time"
 
 	"github.com/jarrettv/go-japi/decoder"
 	"github.com/jarrettv/go-japi/problem"
@@ -16,11 +19,24 @@ type StatusCoder interface {
 	StatusCode() int
 }
 
-// Headerer allows you to customise the HTTP headers.
+// Headerer allows you to customise the HTTP headers. Errors that are also a
+// Problemer send the headers with the problem.
 type Headerer interface {
 	Header() http.Header
 }
 
+// Locationer allows you to set the Location header of the response. POST
+// requests will respond with 201 Created unless a StatusCoder is implemented.
+type Locationer interface {
+	Location() string
+}
+
+// RouteLocationer allows you to set the Location header of the response using
+// the named route and param key value pairs. See Locationer.
+type RouteLocationer interface {
+	LocationRoute() (name string, params []string)
+}
+
 // Problemer allows you to customize the error problem details.
 type Problemer interface {
 	Problem() problem.Problem
@@ -35,9 +51,21 @@ type Handler interface {
 // H wraps your handler function with the Go generics magic.
 func H[T any, O any](handle Handle[T, O]) Handler {
 	h := &handler[T, O]{handler: handle}
+	h.pool = &sync.Pool{New: func() any { return new(T) }}
 
 	var t T
 
+	h.decodeHooks = hasDecodeHooks(reflect.TypeOf(&t).Elem())
+	h.redact = hasRedactTag(reflect.TypeOf(&t).Elem())
+	h.validator = newValidator(reflect.TypeOf(&t).Elem())
+	h.rawRequest = reflect.PointerTo(reflect.TypeOf(&t).Elem()).Implements(rawRequesterType) ||
+		reflect.TypeOf(&t).Elem().Implements(rawRequesterType)
+	h.resValidator = newValidator(reflect.TypeOf((*O)(nil)).Elem())
+	h.rawBody = newRawBody(reflect.TypeOf(&t).Elem())
+	h.listQuery = newListDecoder(reflect.TypeOf(&t).Elem())
+	h.encodeHeader = newHeaderEncoder(reflect.TypeOf((*O)(nil)).Elem())
+	addResponseType(reflect.TypeOf((*O)(nil)).Elem())
+
 	if hasTag(t, headerTag) {
 		dec, err := decoder.NewCachedDecoder(t, headerTag)
 		if err == nil {
@@ -77,7 +105,7 @@ func LoadRawJson(loadRawJson func() ([]byte, error)) http.HandlerFunc {
 			http.Error(w, e.Error(), http.StatusInternalServerError)
 			return
 		}
-		RawJson(json)
+		RawJson(json).ServeHTTP(w, r)
 	})
 }
 
@@ -96,12 +124,23 @@ func RawJson(data []byte) http.HandlerFunc {
 type Handle[T any, O any] func(ctx context.Context, request T) (O, error)
 
 type handler[T any, O any] struct {
-	config       *Config
+	config       *configRef
+	api          *API
+	route        *Route
 	handler      Handle[T, O]
 	decodeHeader *decoder.CachedDecoder
 	decodePath   *decoder.ParamsDecoder
 	decodeQuery  *decoder.MapDecoder
+	listQuery    *listDecoder
+	decodeHooks  bool
+	redact       bool
+	validator    *structValidator
+	rawRequest   bool
+	resValidator *structValidator
+	rawBody      func(r *http.Request, v any) error
+	encodeHeader headerEncoder
 	isNil        func(v any) bool
+	pool         *sync.Pool
 }
 
 func (h *handler[T, O]) ServeHTTP(w http.ResponseWriter, r *http.Request) {
@@ -110,91 +149,231 @@ func (h *handler[T, O]) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 //nolint:gocognit,cyclop
 func (h *handler[T, O]) handle(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
-	if h.config.RouteLogFunc != nil {
+	config := h.config.Load().forTenant(r.Context())
+
+	if config.RouteLogFunc != nil {
 		route := p.MatchedRoutePath()
-		vars := make(map[string]string, len(p))
-		for _, param := range p {
-			if param.Value != route {
-				vars[param.Key] = param.Value
+		config.RouteLogFunc(r.Context(), route, routeParams(route, p)) // TODO (jv) get route
+	} else if config.Logger != nil {
+		sw, start := newStatusWriter(w), time.Now()
+		w = sw
+		defer func() {
+			v := recover()
+			if v != nil {
+				sw.status = http.StatusInternalServerError // the panic handler serves it
 			}
-		}
-		h.config.RouteLogFunc(r.Context(), route, vars) // TODO (jv) get route
+			config.logRequest(r, p.MatchedRoutePath(), p, start, sw.status)
+			if v != nil {
+				panic(v)
+			}
+		}()
+	}
+
+	var audited any
+	if a := config.Audit; a != nil {
+		sw, start := newStatusWriter(w), time.Now()
+		w = sw
+		defer func() {
+			v := recover()
+			if v != nil {
+				sw.status = http.StatusInternalServerError
+			}
+			a.audit(r, p.MatchedRoutePath(), start, sw, audited)
+			if v != nil {
+				panic(v)
+			}
+		}()
 	}
 
 	serveProblem := func(p *problem.Problem) {
-		h.config.Enrich(r.Context(), p)
-		if h.config.ProblemLogFunc != nil {
-			h.config.ProblemLogFunc(r.Context(), p)
-		}
-		p.ServeJSON(w)
+		config.serveProblem(w, r, p)
 	}
 
 	serveRequestProblem := func(e error) {
+		if p, ok := asProblem(e); ok {
+			serveProblem(p)
+			return
+		}
 		p := problem.BadRequest(e)
 		serveProblem(p)
 	}
 
-	req := new(T)
+	var req *T
+	if config.ReuseRequests {
+		req = h.pool.Get().(*T)
+		defer h.release(req)
+	} else {
+		req = new(T)
+	}
 
-	// Decode the header
-	if h.decodeHeader != nil {
-		e := h.decodeHeader.Decode(r.Header, req)
-		if e != nil {
+	spanEvent(r.Context(), "decode")
+
+	hooks := h.decodeHooks || config.BeforeDecode != nil || config.AfterDecode != nil
+	if hooks {
+		if e := runBeforeDecode(config, r, decodeTarget(req)); e != nil {
 			serveRequestProblem(e)
 			return
 		}
 	}
 
+	errs := problem.Errors{}
+
+	// Decode the header
+	if h.decodeHeader != nil {
+		if e := h.decodeHeader.Decode(r.Header, req); e != nil {
+			addDecodeError(errs, headerTag, e)
+		}
+	}
+
 	// Decode the URL query
 	if h.decodeQuery != nil && r.URL.RawQuery != "" {
-		e := h.decodeQuery.Decode(r.URL.Query(), req)
-		if e != nil {
-			serveRequestProblem(e)
-			return
+		if e := h.decodeQuery.Decode(r.URL.Query(), req); e != nil {
+			addDecodeError(errs, queryTag, e)
 		}
 	}
 
 	// Decode the path params
 	if h.decodePath != nil && len(p) != 0 {
-		e := h.decodePath.Decode(p, req)
-		if e != nil {
-			serveRequestProblem(e)
-			return
+		if e := h.decodePath.DecodeURL(r.URL, p, req); e != nil {
+			addDecodeError(errs, pathTag, e)
 		}
 	}
 
+	// Decode the list query
+	if h.listQuery != nil {
+		h.listQuery.decode(r.URL.Query(), decodeTarget(req), errs)
+	}
+
+	// Parse the sparse fieldset
+	var fields fieldSet
+	if h.route != nil && h.route.fields != nil {
+		fields = h.route.fields.parse(r.URL.Query(), errs)
+	}
+
 	// Decode the body
-	if r.ContentLength > 0 {
-		dec, e := getDecoder(JsonEncoding)
-		if e != nil {
-			serveRequestProblem(e) // http.ErrNotSupported
+	if !limitBody(w, r, h.maxBodyBytes(config)) {
+		serveProblem(problem.Status(http.StatusRequestEntityTooLarge))
+		return
+	}
+
+	if h.rawBody != nil {
+		if e := h.rawBody(r, req); isTooLarge(e) {
+			serveProblem(problem.Status(http.StatusRequestEntityTooLarge))
+			return
+		} else if e != nil {
+			serveRequestProblem(e)
+			return
+		}
+	} else if r.ContentLength > 0 || r.ContentLength < 0 && r.Body != nil && r.Body != http.NoBody {
+		dec, p := h.requestDecoder(r, config)
+		if p != nil {
+			serveProblem(p)
 			return
 		}
 
-		if e := dec(r, req); e != nil {
+		// a chunked body may be empty
+		if e := h.decodeBody(r, config, dec, req, errs); e != nil && (r.ContentLength > 0 || e != io.EOF) {
+			if _, ok := asProblem(e); ok {
+				serveRequestProblem(e)
+				return
+			}
+			if isTooLarge(e) {
+				serveProblem(problem.Status(http.StatusRequestEntityTooLarge))
+				return
+			}
+			addDecodeError(errs, bodyField, e)
+		}
+	}
+
+	if len(errs) > 0 {
+		serveProblem(problem.ValidationErrors(errs))
+		return
+	}
+
+	if h.rawRequest {
+		decodeTarget(req).(rawRequester).setRawRequest(r)
+	}
+
+	if hooks {
+		if e := runAfterDecode(config, r, decodeTarget(req)); e != nil {
 			serveRequestProblem(e)
 			return
 		}
 	}
 
+	if h.validator != nil || config.Validator != nil {
+		if errs := validateRequest(config, h.validator, decodeTarget(req)); len(errs) > 0 {
+			serveProblem(problem.ValidationErrors(errs))
+			return
+		}
+	}
+
+	if config.Audit != nil {
+		audited = h.auditRequest(*req)
+	}
+
+	spanEvent(r.Context(), "handle")
+
+	rw := newStatusWriter(w)
+	ctx := context.WithValue(r.Context(), writerKey{}, http.ResponseWriter(rw))
+
 	var res any
-	res, e := h.handler(r.Context(), *req)
-	w.Header().Set("Content-Type", JsonEncoding+"; charset=utf-8")
+	res, e := h.handler(ctx, *req)
+	if timedOut(w) {
+		return // the timeout problem has been served
+	}
+	if rw.status != 0 {
+		return // the handler wrote the response
+	}
+
 	if e != nil {
-		if pb, ok := e.(Problemer); ok {
-			p := pb.Problem()
-			serveProblem(&p)
-			return
-		} else if p, ok := e.(*problem.Problem); ok {
+		if isShutdown(r.Context()) {
+			serveProblem(shutdownProblem())
+		} else if p, ok := asProblem(e); ok {
 			serveProblem(p)
-			return
+		} else if errors.Is(e, context.DeadlineExceeded) {
+			serveProblem(problem.Timeout())
 		} else {
-			p := problem.Unexpected(e)
-			serveProblem(p)
+			serveProblem(problem.Unexpected(e))
+		}
+		return
+	}
+
+	if config.ValidateResponses {
+		if e := h.validateResponse(res); e != nil {
+			serveProblem(problem.Unexpected(e))
 			return
 		}
 	}
 
+	if h.route != nil && h.route.cacheControl != "" {
+		w.Header().Set("Cache-Control", h.route.cacheControl)
+	}
+
+	fileStatus := 0
+	if h.route != nil {
+		fileStatus = h.route.status
+	}
+	if serveFile(w, r, res, fileStatus) || serveRedirect(w, r, res) {
+		return
+	}
+
+	output := h.output(config, r)
+	if h.negotiates(config) {
+		addVary(w.Header(), "Accept")
+	}
+	contentType, encoder := "", Encoder(nil)
+	if output == nil {
+		contentType, encoder = getResponseEncoder(r, reflect.TypeOf(res))
+	}
+	switch {
+	case output != nil:
+		w.Header().Set("Content-Type", output.ContentType)
+	case encoder != nil:
+		w.Header().Set("Content-Type", contentType)
+	default:
+		w.Header().Set("Content-Type", JsonEncoding+"; charset=utf-8")
+	}
 	if h, ok := res.(Headerer); ok {
 		headers := w.Header()
 		for k, v := range h.Header() {
@@ -202,22 +381,153 @@ func (h *handler[T, O]) handle(w http.ResponseWriter, r *http.Request, p httprou
 		}
 	}
 
+	body := res
+	if h.encodeHeader != nil {
+		body = h.encodeHeader(w.Header(), res, config.JSONMarshal)
+		if encoder != nil {
+			body = res // the header fields are only left out of json
+		}
+	}
+
+	if notModified(w, r, res) {
+		return
+	}
+
+	status := 0
+	if loc, e := h.location(res); e != nil {
+		serveProblem(problem.Unexpected(e))
+		return
+	} else if loc != "" {
+		w.Header().Set("Location", loc)
+		if r.Method == http.MethodPost {
+			status = http.StatusCreated
+		}
+	}
+
+	if h.route != nil && h.route.status != 0 {
+		status = h.route.status
+	}
 	if sc, ok := res.(StatusCoder); ok {
-		w.WriteHeader(sc.StatusCode())
+		status = sc.StatusCode()
+	}
+
+	if status == http.StatusNoContent {
+		w.Header().Del("Content-Type")
+		w.WriteHeader(status)
+		return
+	}
+	if status != 0 {
+		w.WriteHeader(status)
+	}
+
+	stream, _ := unwrapBody(body).(streamer)
+	if stream != nil && (output != nil || encoder != nil || config.ResponseWrapper != nil) {
+		if body, e = stream.collect(r.Context()); e != nil {
+			serveProblem(problem.Unexpected(e))
+			return
+		}
+		stream = nil
+	}
+
+	if output != nil {
+		if body, e = output.Shape(r, body); e != nil {
+			serveProblem(problem.Unexpected(e))
+			return
+		}
+	} else if config.ResponseWrapper != nil {
+		body = config.ResponseWrapper(r.Context(), p.MatchedRoutePath(), unwrapBody(body))
+		if h.encodeHeader != nil && encoder == nil && reflect.TypeOf(body) == reflect.TypeOf(res) {
+			// the header fields are still left out of the response returned as is
+			body = h.encodeHeader(http.Header{}, body, config.JSONMarshal)
+		}
+	}
+
+	spanEvent(r.Context(), "encode")
+
+	opts := config.Encode
+	if h.route != nil && h.route.encode != nil {
+		opts = *h.route.encode
+	}
+	opts.fields = fields
+	opts.marshal = config.JSONMarshal
+
+	if stream != nil {
+		started, e := stream.streamJSON(w, r, opts)
+		switch {
+		case e == nil || r.Context().Err() != nil:
+			// the client is gone
+		case started:
+			// the truncated array is invalid json
+			config.prepareProblem(withRequest(r), problem.Unexpected(e))
+		default:
+			serveProblem(problem.Unexpected(e))
+		}
+		return
 	}
 
-	if e = json.NewEncoder(w).Encode(res); e != nil {
+	if encoder != nil {
+		e = encoder(w, body)
+	} else {
+		e = encodeJSON(w, r, body, opts)
+	}
+	if e != nil {
 		p := problem.Unexpected(e)
 		serveProblem(p)
 	}
 }
 
-func (h *handler[T, O]) setConfig(r *Config) {
+// release clears the request and puts it back in the pool.
+func (h *handler[T, O]) release(req *T) {
+	var zero T
+	*req = zero
+	h.pool.Put(req)
+}
+
+// auditRequest returns a copy of the request with the redacted fields cleared.
+func (h *handler[T, O]) auditRequest(req T) any {
+	if h.redact {
+		redact(reflect.ValueOf(&req).Elem())
+	}
+	return req
+}
+
+func (h *handler[T, O]) disallowUnknownFields(config *Config) bool {
+	return h.route.disallowUnknownFields(config)
+}
+
+// location returns the Location header value for the response.
+func (h *handler[T, O]) location(res any) (string, error) {
+	if l, ok := res.(Locationer); ok {
+		return l.Location(), nil
+	}
+
+	if l, ok := res.(RouteLocationer); ok && h.api != nil {
+		name, params := l.LocationRoute()
+		return h.api.URL(name, params...)
+	}
+
+	return "", nil
+}
+
+func (h *handler[T, O]) setConfig(r *configRef) {
 	h.config = r
 }
 
+// types returns the request and response types.
+func (h *handler[T, O]) types() (reflect.Type, reflect.Type) {
+	return reflect.TypeOf((*T)(nil)).Elem(), reflect.TypeOf((*O)(nil)).Elem()
+}
+
+func (h *handler[T, O]) withRoute(a *API, rt *Route) Handler {
+	hc := *h
+	hc.api = a
+	hc.route = rt
+	return &hc
+}
+
 const (
 	headerTag = "header"
 	pathTag   = "path"
 	queryTag  = "query"
+	bodyField = "body"
 )
@@ -0,0 +1,159 @@
+package japi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/goccy/go-json"
+)
+
+// DefaultHealthTimeout is the timeout of checks without their own timeout.
+var DefaultHealthTimeout = 5 * time.Second
+
+// HealthChecker checks a dependency of the API is ready. Checkers can also
+// implement Timeout() time.Duration and Critical() bool.
+type HealthChecker interface {
+	Name() string
+	Check(ctx context.Context) error
+}
+
+type healthCheck struct {
+	name     string
+	timeout  time.Duration
+	critical bool
+	check    func(ctx context.Context) error
+}
+
+func (c *healthCheck) Name() string                    { return c.name }
+func (c *healthCheck) Check(ctx context.Context) error { return c.check(ctx) }
+func (c *healthCheck) Timeout() time.Duration          { return c.timeout }
+func (c *healthCheck) Critical() bool                  { return c.critical }
+
+// Check creates a critical health checker. A zero timeout uses the default.
+func Check(name string, timeout time.Duration, check func(ctx context.Context) error) HealthChecker {
+	return &healthCheck{name: name, timeout: timeout, critical: true, check: check}
+}
+
+// NonCritical creates a health checker that is reported but does not fail readiness.
+func NonCritical(name string, timeout time.Duration, check func(ctx context.Context) error) HealthChecker {
+	return &healthCheck{name: name, timeout: timeout, check: check}
+}
+
+// HealthStatus is the body of the health and readiness endpoints.
+type HealthStatus struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckStatus `json:"checks,omitempty"`
+}
+
+// CheckStatus is the result of a health check.
+type CheckStatus struct {
+	Status   string `json:"status"`
+	Duration string `json:"duration"`
+	Error    string `json:"error,omitempty"`
+}
+
+const (
+	healthOK       = "ok"
+	healthFail     = "fail"
+	healthDegraded = "degraded"
+)
+
+// Health registers the liveness endpoint that responds when the API is serving.
+func (r *API) Health(path string) {
+	r.Get(path, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		writeHealth(w, http.StatusOK, HealthStatus{Status: healthOK})
+	}))
+}
+
+// Ready registers the readiness endpoint that runs the checks concurrently and
+// responds with 503 Service Unavailable when any critical check fails.
+func (r *API) Ready(path string, checks ...HealthChecker) {
+	r.Get(path, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		status := runHealthChecks(req.Context(), checks, r.config.Load().ExposeInternalErrors)
+
+		code := http.StatusOK
+		if status.Status == healthFail {
+			code = http.StatusServiceUnavailable
+		}
+		writeHealth(w, code, status)
+	}))
+}
+
+func runHealthChecks(ctx context.Context, checks []HealthChecker, expose bool) HealthStatus {
+	status := HealthStatus{Status: healthOK, Checks: make(map[string]CheckStatus, len(checks))}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, c := range checks {
+		wg.Add(1)
+		go func(c HealthChecker) {
+			defer wg.Done()
+
+			timeout := DefaultHealthTimeout
+			if t, ok := c.(interface{ Timeout() time.Duration }); ok && t.Timeout() > 0 {
+				timeout = t.Timeout()
+			}
+			critical := true
+			if cr, ok := c.(interface{ Critical() bool }); ok {
+				critical = cr.Critical()
+			}
+
+			cctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+
+			start := time.Now()
+			err := runCheck(cctx, c)
+			cs := CheckStatus{Status: healthOK, Duration: time.Since(start).String()}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				cs.Status = healthFail
+				cs.Error = "check failed"
+				if expose {
+					cs.Error = err.Error()
+				}
+				if critical {
+					status.Status = healthFail
+				} else if status.Status == healthOK {
+					status.Status = healthDegraded
+				}
+			}
+			status.Checks[c.Name()] = cs
+		}(c)
+	}
+	wg.Wait()
+
+	return status
+}
+
+// runCheck runs the check until the context is done. Checks that ignore the
+// context are left running and panics fail the check.
+func runCheck(ctx context.Context, c HealthChecker) error {
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if v := recover(); v != nil {
+				done <- fmt.Errorf("japi: health check panic: %v", v)
+			}
+		}()
+		done <- c.Check(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func writeHealth(w http.ResponseWriter, code int, status HealthStatus) {
+	w.Header().Set("Content-Type", JsonEncoding+"; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(status)
+}
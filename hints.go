@@ -0,0 +1,30 @@
+package japi
+
+import (
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// WithEarlyHints sends a 103 Early Hints response with the Link headers before
+// the handler runs so browsers can preload the resources e.g.
+// </app.css>; rel=preload; as=style. The links are also sent with the final
+// response.
+func WithEarlyHints(links ...string) RouteOption {
+	return func(rt *Route) {
+		rt.hints = append(rt.hints, links...)
+	}
+}
+
+// earlyHints wraps the handle to send the links as early hints.
+func earlyHints(links []string, next httprouter.Handle) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+		if r.ProtoAtLeast(1, 1) {
+			for _, link := range links {
+				w.Header().Add("Link", link)
+			}
+			w.WriteHeader(http.StatusEarlyHints)
+		}
+		next(w, r, p)
+	}
+}
@@ -0,0 +1,95 @@
+package japi
+
+import (
+	"net/http"
+	"reflect"
+
+	"github.com/jarrettv/go-japi/problem"
+)
+
+// DecodeHook is called with the raw request and a pointer to the request struct.
+type DecodeHook func(r *http.Request, v any) error
+
+// BeforeDecoder allows your request to run before it is decoded.
+type BeforeDecoder interface {
+	BeforeDecode(r *http.Request) error
+}
+
+// AfterDecoder allows your request to run after it is decoded.
+type AfterDecoder interface {
+	AfterDecode(r *http.Request) error
+}
+
+var (
+	beforeDecoderType = reflect.TypeOf((*BeforeDecoder)(nil)).Elem()
+	afterDecoderType  = reflect.TypeOf((*AfterDecoder)(nil)).Elem()
+)
+
+// hasDecodeHooks reports whether the request type implements the hooks.
+func hasDecodeHooks(t reflect.Type) bool {
+	if t == nil {
+		return false
+	}
+	if t.Kind() != reflect.Pointer {
+		t = reflect.PointerTo(t)
+	}
+	return t.Implements(beforeDecoderType) || t.Implements(afterDecoderType)
+}
+
+// decodeTarget returns the pointer to the request struct, allocating it when
+// the request type is a pointer.
+func decodeTarget[T any](req *T) any {
+	v := reflect.ValueOf(req).Elem()
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return v.Interface()
+	}
+	return req
+}
+
+func runBeforeDecode(c *Config, r *http.Request, v any) error {
+	if c.BeforeDecode != nil {
+		if err := c.BeforeDecode(r, v); err != nil {
+			return err
+		}
+	}
+	if bd, ok := v.(BeforeDecoder); ok {
+		return bd.BeforeDecode(r)
+	}
+	return nil
+}
+
+func runAfterDecode(c *Config, r *http.Request, v any) error {
+	if ad, ok := v.(AfterDecoder); ok {
+		if err := ad.AfterDecode(r); err != nil {
+			return err
+		}
+	}
+	if c.AfterDecode != nil {
+		return c.AfterDecode(r, v)
+	}
+	return nil
+}
+
+// asProblem returns the problem details for errors that carry them. The
+// headers of errors that are also a Headerer are sent with the problem.
+func asProblem(e error) (*problem.Problem, bool) {
+	if pb, ok := e.(Problemer); ok {
+		p := pb.Problem()
+		if h, ok := e.(Headerer); ok {
+			p.Headers = p.Headers.Clone()
+			if p.Headers == nil {
+				p.Headers = http.Header{}
+			}
+			for k, v := range h.Header() {
+				p.Headers[k] = v
+			}
+		}
+		return &p, true
+	} else if p, ok := e.(*problem.Problem); ok {
+		return p, true
+	}
+	return nil, false
+}
@@ -0,0 +1,46 @@
+package japi
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// Host serves the requests for the host with the child API and its own config,
+// middleware and cron funcs. A host starting with *. matches the subdomains e.g.
+// *.example.com. Requests for other hosts are served by the API.
+func (r *API) Host(host string, child *API) {
+	if r.hosts == nil {
+		r.hosts = map[string]*API{}
+	}
+	r.hosts[strings.ToLower(host)] = child
+	r.children = append(r.children, child)
+}
+
+// hostHandler dispatches the requests by the Host header.
+func (r *API) hostHandler(next http.Handler) http.Handler {
+	hosts := make(map[string]http.Handler, len(r.hosts))
+	for host, child := range r.hosts {
+		hosts[host] = child.Router()
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		host := strings.ToLower(req.Host)
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+
+		if h, ok := hosts[host]; ok {
+			h.ServeHTTP(w, req)
+			return
+		}
+		for i := strings.IndexByte(host, '.'); i >= 0; i = strings.IndexByte(host, '.') {
+			host = host[i+1:]
+			if h, ok := hosts["*."+host]; ok {
+				h.ServeHTTP(w, req)
+				return
+			}
+		}
+		next.ServeHTTP(w, req)
+	})
+}
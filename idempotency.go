@@ -0,0 +1,299 @@
+package japi
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/jarrettv/go-japi/problem"
+)
+
+// IdempotencyHeader is the request header with the idempotency key.
+const IdempotencyHeader = "Idempotency-Key"
+
+var idempotencyInFlight = problem.Register("idempotency-in-flight", http.StatusConflict, "Request in progress").
+	Describe("A request with the same Idempotency-Key is still being handled")
+
+var idempotencyKeyReused = problem.Register("idempotency-key-reused", http.StatusUnprocessableEntity,
+	"Idempotency key reused").
+	Describe("The Idempotency-Key was already used for a request with a different body")
+
+// StoredResponse is the response stored for an idempotency key.
+type StoredResponse struct {
+	Status int
+	Header http.Header
+	Body   []byte
+	// Fingerprint is the hash of the request body, duplicates with another body
+	// respond with a 422 problem.
+	Fingerprint string
+}
+
+// IdempotencyStore stores the responses of idempotent requests.
+type IdempotencyStore interface {
+	// Get returns the stored response of the key.
+	Get(ctx context.Context, key string) (*StoredResponse, bool, error)
+	// Reserve marks the key in flight, returning false when it is already reserved or stored.
+	Reserve(ctx context.Context, key string, ttl time.Duration) (bool, error)
+	// Set stores the response of the reserved key.
+	Set(ctx context.Context, key string, res *StoredResponse, ttl time.Duration) error
+	// Release removes the reservation of the key so it can be retried.
+	Release(ctx context.Context, key string) error
+}
+
+type idempotency struct {
+	store  IdempotencyStore
+	ttl    time.Duration
+	caller func(r *http.Request) string
+}
+
+// IdempotencyOption configures the idempotency of a route.
+type IdempotencyOption func(*idempotency)
+
+// IdempotencyCaller scopes the keys to the caller returned by the func e.g. the
+// user id. The default is a hash of the Authorization and Cookie headers and
+// the tenant.
+func IdempotencyCaller(caller func(r *http.Request) string) IdempotencyOption {
+	return func(idem *idempotency) {
+		idem.caller = caller
+	}
+}
+
+// WithIdempotency stores the first response of requests with an Idempotency-Key
+// header and replays it for duplicate requests of the same caller within the
+// ttl. Duplicates with a different body respond with a 422 problem and
+// duplicates of a request in flight with a 409 problem. Server errors are not
+// stored.
+func WithIdempotency(store IdempotencyStore, ttl time.Duration, opts ...IdempotencyOption) RouteOption {
+	idem := &idempotency{store: store, ttl: ttl, caller: credentials}
+	for _, opt := range opts {
+		opt(idem)
+	}
+
+	return func(rt *Route) {
+		rt.idempotency = idem
+	}
+}
+
+// credentials returns a hash of the Authorization and Cookie headers and the
+// tenant of the request.
+func credentials(r *http.Request) string {
+	h := sha256.New()
+	for _, name := range []string{"Authorization", "Cookie"} {
+		for _, v := range r.Header.Values(name) {
+			h.Write([]byte(v))
+			h.Write([]byte{0})
+		}
+		h.Write([]byte{1})
+	}
+	h.Write([]byte(Tenant(r.Context())))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// idempotent wraps the handle to store and replay the responses.
+func idempotent(ref *configRef, rt *Route, idem *idempotency, next httprouter.Handle) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+		key := r.Header.Get(IdempotencyHeader)
+		if key == "" {
+			next(w, r, p)
+			return
+		}
+
+		c, ctx := ref.Load(), r.Context()
+		key = r.Method + " " + rt.Path + " " + idem.caller(r) + " " + key
+
+		if res, ok, err := idem.store.Get(ctx, key); err != nil {
+			c.serveProblem(w, r, problem.Unexpected(err))
+			return
+		} else if ok {
+			replay(w, r, c, rt, res)
+			return
+		}
+
+		if ok, err := idem.store.Reserve(ctx, key, idem.ttl); err != nil {
+			c.serveProblem(w, r, problem.Unexpected(err))
+			return
+		} else if !ok {
+			if res, ok, _ := idem.store.Get(ctx, key); ok {
+				replay(w, r, c, rt, res)
+				return
+			}
+			c.serveProblem(w, r, idempotencyInFlight.New("A request with the same Idempotency-Key is in progress"))
+			return
+		}
+
+		rec := &recordWriter{statusWriter: newStatusWriter(w)}
+		stored := false
+		defer func() {
+			if !stored {
+				_ = idem.store.Release(context.WithoutCancel(ctx), key)
+			}
+		}()
+
+		body := &hashBody{ReadCloser: r.Body, hash: sha256.New()}
+		r.Body = body
+		next(rec, r, p)
+
+		if status := rec.Status(); status < http.StatusInternalServerError {
+			res := &StoredResponse{Status: status, Header: rec.header, Body: rec.body.Bytes()}
+			if rec.header == nil {
+				res.Header = w.Header().Clone()
+			}
+			if fp, err := body.sum(rt.maxBodyBytes(c)); err == nil {
+				res.Fingerprint = fp
+				stored = idem.store.Set(context.WithoutCancel(ctx), key, res, idem.ttl) == nil
+			}
+		}
+	}
+}
+
+// replay writes the stored response when the request has the same body.
+func replay(w http.ResponseWriter, r *http.Request, c *Config, rt *Route, res *StoredResponse) {
+	if res.Fingerprint != "" {
+		body := &hashBody{ReadCloser: r.Body, hash: sha256.New()}
+		if fp, err := body.sum(rt.maxBodyBytes(c)); err != nil || fp != res.Fingerprint {
+			c.serveProblem(w, r, idempotencyKeyReused.New(
+				"The body differs from the first request with the same Idempotency-Key"))
+			return
+		}
+	}
+
+	w.Header().Set("Idempotent-Replayed", "true")
+	writeStored(w, res)
+}
+
+// hashBody hashes the request body as it is read.
+type hashBody struct {
+	io.ReadCloser
+	hash hash.Hash
+	read int64
+}
+
+func (b *hashBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.hash.Write(p[:n])
+	b.read += int64(n)
+	return n, err
+}
+
+// sum reads the rest of the body up to the max and returns the hash. Bodies
+// past the max are not hashed.
+func (b *hashBody) sum(max int64) (string, error) {
+	rest := io.Reader(b)
+	if max > 0 {
+		rest = io.LimitReader(b, max-b.read+1)
+	}
+	if _, err := io.Copy(io.Discard, rest); err != nil {
+		return "", err
+	}
+	if max > 0 && b.read > max {
+		return "", &http.MaxBytesError{Limit: max}
+	}
+	return hex.EncodeToString(b.hash.Sum(nil)), nil
+}
+
+// writeStored writes the stored response.
+func writeStored(w http.ResponseWriter, res *StoredResponse) {
+	for k, v := range res.Header {
+		w.Header()[k] = v
+	}
+	w.WriteHeader(res.Status)
+	_, _ = w.Write(res.Body)
+}
+
+// recordWriter records the headers and body written to the response.
+type recordWriter struct {
+	*statusWriter
+	header http.Header
+	body   bytes.Buffer
+}
+
+func (w *recordWriter) WriteHeader(code int) {
+	if w.header == nil {
+		w.header = w.Header().Clone()
+	}
+	w.statusWriter.WriteHeader(code)
+}
+
+func (w *recordWriter) Write(b []byte) (int, error) {
+	if w.header == nil {
+		w.header = w.Header().Clone()
+	}
+	w.body.Write(b)
+	return w.statusWriter.Write(b)
+}
+
+// MemoryIdempotencyStore is an in memory IdempotencyStore for a single instance.
+type MemoryIdempotencyStore struct {
+	mu      sync.Mutex
+	entries map[string]*idempotencyEntry
+}
+
+type idempotencyEntry struct {
+	res     *StoredResponse
+	expires time.Time
+}
+
+// NewMemoryIdempotencyStore creates an in memory IdempotencyStore.
+func NewMemoryIdempotencyStore() *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{entries: map[string]*idempotencyEntry{}}
+}
+
+func (s *MemoryIdempotencyStore) entry(key string) *idempotencyEntry {
+	e, ok := s.entries[key]
+	if ok && time.Now().After(e.expires) {
+		delete(s.entries, key)
+		return nil
+	}
+	return e
+}
+
+// Get returns the stored response of the key.
+func (s *MemoryIdempotencyStore) Get(_ context.Context, key string) (*StoredResponse, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e := s.entry(key); e != nil && e.res != nil {
+		return e.res, true, nil
+	}
+	return nil, false, nil
+}
+
+// Reserve marks the key in flight.
+func (s *MemoryIdempotencyStore) Reserve(_ context.Context, key string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e := s.entry(key); e != nil {
+		return false, nil
+	}
+	s.entries[key] = &idempotencyEntry{expires: time.Now().Add(ttl)}
+	return true, nil
+}
+
+// Set stores the response of the key.
+func (s *MemoryIdempotencyStore) Set(_ context.Context, key string, res *StoredResponse, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries[key] = &idempotencyEntry{res: res, expires: time.Now().Add(ttl)}
+	return nil
+}
+
+// Release removes the reservation of the key.
+func (s *MemoryIdempotencyStore) Release(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e, ok := s.entries[key]; ok && e.res == nil {
+		delete(s.entries, key)
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package japi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestIdempotency(t *testing.T) {
+	var calls atomic.Int32
+	r := New(nil)
+	r.UpdateConfig(func(c *Config) { c.Logger = nil })
+	r.Post("/orders", H(func(_ context.Context, req itemsRequest) (*itemsRequest, error) {
+		calls.Add(1)
+		if req.Name == "fail" {
+			return nil, errors.New("failed")
+		}
+		return &req, nil
+	}), WithIdempotency(NewMemoryIdempotencyStore(), time.Minute))
+	h := r.Router()
+
+	// The steps share the store so each builds on the previous ones.
+	tests := []struct {
+		name     string
+		key      string
+		auth     string
+		body     string
+		status   int
+		replayed bool
+		calls    int32
+	}{
+		{"first", "a", "alice", `{"name":"x"}`, 200, false, 1},
+		{"replayed", "a", "alice", `{"name":"x"}`, 200, true, 1},
+		{"different body", "a", "alice", `{"name":"y"}`, 422, false, 1},
+		{"other caller", "a", "bob", `{"name":"y"}`, 200, false, 2},
+		{"other key", "b", "alice", `{"name":"y"}`, 200, false, 3},
+		{"no key", "", "alice", `{"name":"x"}`, 200, false, 4},
+		{"no key again", "", "alice", `{"name":"x"}`, 200, false, 5},
+		{"server error", "c", "alice", `{"name":"fail"}`, 500, false, 6},
+		{"server error retried", "c", "alice", `{"name":"fail"}`, 500, false, 7},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/orders", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", JsonEncoding)
+			req.Header.Set("Authorization", tt.auth)
+			if tt.key != "" {
+				req.Header.Set(IdempotencyHeader, tt.key)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+
+			if w.Code != tt.status {
+				t.Fatalf("status %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+			if replayed := w.Header().Get("Idempotent-Replayed") == "true"; replayed != tt.replayed {
+				t.Errorf("replayed %v, want %v", replayed, tt.replayed)
+			}
+			if n := calls.Load(); n != tt.calls {
+				t.Errorf("handled %d times, want %d", n, tt.calls)
+			}
+			if tt.status == 422 && !strings.Contains(w.Body.String(), "idempotency-key-reused") {
+				t.Errorf("body %s is not the key reused problem", w.Body)
+			}
+		})
+	}
+}
+
+func TestIdempotencyInFlight(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	r := New(nil)
+	r.UpdateConfig(func(c *Config) { c.Logger = nil })
+	r.Post("/orders", H(func(_ context.Context, req itemsRequest) (*itemsRequest, error) {
+		close(started)
+		<-release
+		return &req, nil
+	}), WithIdempotency(NewMemoryIdempotencyStore(), time.Minute))
+	h := r.Router()
+
+	serve := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/orders", strings.NewReader(`{"name":"x"}`))
+		req.Header.Set("Content-Type", JsonEncoding)
+		req.Header.Set(IdempotencyHeader, "a")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	first := make(chan *httptest.ResponseRecorder)
+	go func() { first <- serve() }()
+	<-started
+
+	if w := serve(); w.Code != http.StatusConflict || !strings.Contains(w.Body.String(), "idempotency-in-flight") {
+		t.Errorf("in flight duplicate %d: %s", w.Code, w.Body)
+	}
+
+	close(release)
+	if w := <-first; w.Code != http.StatusOK {
+		t.Fatalf("first %d: %s", w.Code, w.Body)
+	}
+	if w := serve(); w.Code != http.StatusOK || w.Header().Get("Idempotent-Replayed") != "true" {
+		t.Errorf("duplicate after the first finished %d %v: %s", w.Code, w.Header(), w.Body)
+	}
+}
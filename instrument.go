@@ -0,0 +1,43 @@
+package japi
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/jarrettv/go-japi/metrics"
+	"github.com/jarrettv/go-japi/problem"
+)
+
+// instrument wraps the handle to record metrics for the route.
+func instrument(rec metrics.Recorder, route string, next httprouter.Handle) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+		start := time.Now()
+		sw := newStatusWriter(w)
+
+		rec.InFlight(route, 1)
+		defer func() {
+			rec.InFlight(route, -1)
+			if v := recover(); v != nil {
+				// the panic handler will serve an internal server error
+				rec.Request(route, r.Method, http.StatusInternalServerError, time.Since(start))
+				rec.Problem(route, http.StatusInternalServerError)
+				panic(v)
+			}
+
+			status := sw.Status()
+			rec.Request(route, r.Method, status, time.Since(start))
+			if tr, ok := rec.(metrics.TenantRecorder); ok {
+				if tenant := Tenant(r.Context()); tenant != "" {
+					tr.TenantRequest(tenant, route, status)
+				}
+			}
+			if ct := sw.Header().Get("Content-Type"); ct == problem.ContentType || ct == problem.XMLContentType {
+				rec.Problem(route, status)
+			}
+		}()
+
+		next(sw, r, p)
+	}
+}
@@ -0,0 +1,243 @@
+// Package japiclient calls japi services using the same request struct tags
+// to place fields into the path, query, header and body.
+package japiclient
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-json"
+
+	"github.com/jarrettv/go-japi/decoder"
+	"github.com/jarrettv/go-japi/problem"
+)
+
+const (
+	headerTag = "header"
+	pathTag   = "path"
+	queryTag  = "query"
+)
+
+// DefaultClient is the client used by Call.
+var DefaultClient = http.DefaultClient
+
+// Call will send the request to the japi route and decode the response. Problem
+// details responses are returned as a *problem.Problem error.
+func Call[Req any, Res any](ctx context.Context, baseURL, method, path string, req Req) (Res, error) {
+	return CallWith[Req, Res](ctx, DefaultClient, baseURL, method, path, req)
+}
+
+// CallWith is Call using the http client.
+func CallWith[Req any, Res any](ctx context.Context, c *http.Client, baseURL, method, path string, req Req) (Res, error) {
+	var res Res
+
+	r, err := NewRequest(ctx, baseURL, method, path, req)
+	if err != nil {
+		return res, err
+	}
+
+	resp, err := c.Do(r)
+	if err != nil {
+		return res, err
+	}
+	defer resp.Body.Close()
+
+	if p, err := problem.Parse(resp); err != nil {
+		return res, err
+	} else if p != nil {
+		return res, p
+	}
+
+	return res, decodeResponse(resp, &res)
+}
+
+// NewRequest will create the http request placing the tagged fields of req
+// into the path, query and header with the remaining fields as the json body.
+func NewRequest[Req any](ctx context.Context, baseURL, method, path string, req Req) (*http.Request, error) {
+	v := reflect.ValueOf(&req).Elem()
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			break
+		}
+		v = v.Elem()
+	}
+
+	query := url.Values{}
+	header := http.Header{}
+	var body any
+
+	if v.Kind() == reflect.Struct {
+		var err error
+		if path, err = encodeFields(v, path, query, header); err != nil {
+			return nil, err
+		}
+		body = bodyOf(v)
+	}
+
+	u := strings.TrimSuffix(baseURL, "/") + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	var reader io.Reader
+	if body != nil && method != http.MethodGet && method != http.MethodHead {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	r, err := http.NewRequestWithContext(ctx, method, u, reader)
+	if err != nil {
+		return nil, err
+	}
+
+	for k, vv := range header {
+		r.Header[k] = vv
+	}
+	r.Header.Set("Accept", "application/json")
+	if reader != nil {
+		r.Header.Set("Content-Type", "application/json")
+	}
+
+	return r, nil
+}
+
+// encodeFields sets the path, query and header tagged fields returning the path.
+func encodeFields(v reflect.Value, path string, query url.Values, header http.Header) (string, error) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue // skip unexported fields
+		}
+
+		if name, ok := f.Tag.Lookup(pathTag); ok {
+			values := fieldValues(v.Field(i))
+			if len(values) == 0 {
+				return "", fmt.Errorf("japiclient: missing path param %q", name)
+			}
+			path = replaceParam(path, name, values[0])
+		}
+
+		if name, ok := f.Tag.Lookup(queryTag); ok {
+			for _, s := range fieldValues(v.Field(i)) {
+				query.Add(name, s)
+			}
+		}
+
+		if name, ok := f.Tag.Lookup(headerTag); ok {
+			for _, s := range fieldValues(v.Field(i)) {
+				header.Add(name, s)
+			}
+		}
+	}
+
+	return path, nil
+}
+
+func replaceParam(path, name, value string) string {
+	segments := strings.Split(path, "/")
+	for i, seg := range segments {
+		switch seg {
+		case ":" + name:
+			segments[i] = url.PathEscape(value)
+		case "*" + name:
+			segments[i] = strings.TrimPrefix(value, "/")
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// bodyOf returns the body without the path, query and header tagged fields
+// that have no json tag, returning nil when there are no body fields.
+func bodyOf(v reflect.Value) any {
+	t := v.Type()
+	fields := []reflect.StructField{}
+	index := []int{}
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" || f.Tag.Get("json") == "-" {
+			continue
+		}
+
+		if f.Anonymous {
+			return v.Interface() // embedded fields cannot be copied to the body type
+		}
+
+		if _, ok := f.Tag.Lookup("json"); !ok && hasParamTag(f) {
+			continue
+		}
+
+		fields = append(fields, f)
+		index = append(index, i)
+	}
+
+	if len(fields) == 0 {
+		return nil
+	}
+	if len(fields) == t.NumField() {
+		return v.Interface()
+	}
+
+	b := reflect.New(reflect.StructOf(fields)).Elem()
+	for i, fi := range index {
+		b.Field(i).Set(v.Field(fi))
+	}
+	return b.Interface()
+}
+
+func hasParamTag(f reflect.StructField) bool {
+	for _, tag := range []string{pathTag, queryTag, headerTag} {
+		if _, ok := f.Tag.Lookup(tag); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// decodeResponse will decode the json body and the header tagged fields.
+func decodeResponse[Res any](resp *http.Response, res *Res) error {
+	if resp.StatusCode != http.StatusNoContent && resp.Request.Method != http.MethodHead {
+		err := json.NewDecoder(resp.Body).Decode(res)
+		if err != nil && err != io.EOF {
+			return err
+		}
+	}
+
+	t := reflect.TypeOf(res).Elem()
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	dec, err := decoder.NewCachedDecoder(reflect.Zero(t).Interface(), headerTag)
+	if err != nil {
+		return nil //nolint:nilerr // no header tags to decode
+	}
+
+	v := reflect.ValueOf(res).Elem()
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			v.Set(reflect.New(t))
+		}
+		return dec.Decode(resp.Header, v.Interface())
+	}
+	return dec.Decode(resp.Header, res)
+}
+
+// fieldValues formats the field as the decoders of the service parse it.
+func fieldValues(v reflect.Value) []string {
+	return decoder.Values(v, time.RFC3339)
+}
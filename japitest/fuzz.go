@@ -0,0 +1,190 @@
+package japitest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jarrettv/go-japi"
+	"github.com/jarrettv/go-japi/decoder"
+)
+
+// fuzzStatuses are the statuses the decoding of a request may respond with.
+var fuzzStatuses = map[int]bool{
+	http.StatusOK:                    true,
+	http.StatusBadRequest:            true,
+	http.StatusRequestEntityTooLarge: true,
+	http.StatusUnsupportedMediaType:  true,
+	http.StatusUnprocessableEntity:   true,
+}
+
+// Fuzz fuzzes the header, query and path params and the json body of the
+// request type T through the decoding of a japi.H handler of a route with the
+// options. The params are url encoded keys and values like a query. It fails
+// when the handler panics, responds with a status other than 200, 400, 413,
+// 415 or 422, responds with a problem that has a field error without a field
+// or message or responds differently to the same input twice. The seed corpus
+// has the decoder.FuzzValues for every tagged field.
+//
+//	func FuzzCreateUser(f *testing.F) {
+//		japitest.Fuzz[CreateUserRequest](f)
+//	}
+func Fuzz[T any](f *testing.F, opts ...japi.RouteOption) {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	headerKeys, queryKeys, pathKeys := tagNames(t, "header", nil), tagNames(t, "query", nil), tagNames(t, "path", nil)
+
+	for i, v := range decoder.FuzzValues {
+		body := decoder.FuzzBodies[i%len(decoder.FuzzBodies)]
+		f.Add(seed(headerKeys, v), seed(queryKeys, v), seed(pathKeys, v), []byte(body))
+	}
+
+	c := japi.GetDefaultConfig()
+	c.Logger = nil
+	c.ProblemInstanceFunc = nil
+	api := japi.New(c)
+
+	var panicked any
+	api.PanicHandler = func(w http.ResponseWriter, _ *http.Request, v any) {
+		panicked = v
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+
+	route := "/fuzz"
+	for _, k := range pathKeys {
+		route += "/:" + k
+	}
+	api.Post(route, japi.H(func(context.Context, T) (*japi.Empty, error) {
+		return &japi.Empty{}, nil
+	}), opts...)
+	h := api.Router()
+
+	f.Fuzz(func(ft *testing.T, headerInput, queryInput, pathInput string, body []byte) {
+		serve := func() *httptest.ResponseRecorder {
+			r := httptest.NewRequest(http.MethodPost, "/fuzz", bytes.NewReader(body))
+			r.Header.Set("Content-Type", japi.JsonEncoding)
+			for k, vs := range parseInput(headerInput) {
+				for _, v := range vs {
+					r.Header.Add(k, v)
+				}
+			}
+
+			params := parseInput(pathInput)
+			for _, k := range pathKeys {
+				r.URL.Path += "/" + pathSegment(params.Get(k))
+			}
+			r.URL.RawPath = ""
+			r.URL.RawQuery = queryInput
+			r.RequestURI = r.URL.RequestURI()
+
+			panicked = nil
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+			if panicked != nil {
+				ft.Fatalf("japitest: decoding panics: %v", panicked)
+			}
+			return w
+		}
+
+		first := serve()
+		checkFuzzResponse(ft, first)
+
+		second := serve()
+		if first.Code != second.Code || first.Body.String() != second.Body.String() {
+			ft.Errorf("japitest: responding to the same input twice differs: %d %s and %d %s",
+				first.Code, first.Body, second.Code, second.Body)
+		}
+	})
+}
+
+// checkFuzzResponse fails unless the response is a success or a problem of a
+// request that does not decode.
+func checkFuzzResponse(t *testing.T, w *httptest.ResponseRecorder) {
+	if !fuzzStatuses[w.Code] {
+		t.Fatalf("japitest: unexpected status %d: %s", w.Code, w.Body)
+	}
+	if w.Code == http.StatusOK {
+		return
+	}
+
+	var p struct {
+		Status int                 `json:"status"`
+		Errors map[string][]string `json:"errors"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil || p.Status != w.Code {
+		t.Fatalf("japitest: the %d response is not a problem: %s", w.Code, w.Body)
+	}
+	for field, msgs := range p.Errors {
+		if field == "" || len(msgs) == 0 {
+			t.Errorf("japitest: field error without a field or message: %s", w.Body)
+		}
+		for _, msg := range msgs {
+			if msg == "" {
+				t.Errorf("japitest: field error without a message: %s", w.Body)
+			}
+		}
+	}
+}
+
+// pathSegment returns the value as a path segment the router matches, which
+// are not empty, dot segments or have slashes.
+func pathSegment(v string) string {
+	v = strings.ReplaceAll(v, "/", "_")
+	if v == "" || v == "." || v == ".." {
+		return "_"
+	}
+	return v
+}
+
+// parseInput parses the url encoded input ignoring the invalid pairs.
+func parseInput(input string) url.Values {
+	values, _ := url.ParseQuery(input)
+	return values
+}
+
+// seed encodes the value for each key.
+func seed(keys []string, v string) string {
+	values := url.Values{}
+	for _, k := range keys {
+		values.Add(k, v)
+	}
+	return values.Encode()
+}
+
+// tagNames returns the names of the tagged fields of the struct and its
+// nested structs. The seen types stop recursive types.
+func tagNames(t reflect.Type, tag string, seen map[reflect.Type]bool) []string {
+	if t.Kind() != reflect.Struct || seen[t] {
+		return nil
+	}
+	if seen == nil {
+		seen = map[reflect.Type]bool{}
+	}
+	seen[t] = true
+
+	var names []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		if name, ok := f.Tag.Lookup(tag); ok {
+			name, _, _ = strings.Cut(name, ",")
+			names = append(names, name)
+			continue
+		}
+		ft := f.Type
+		for ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if ft.Kind() == reflect.Struct {
+			names = append(names, tagNames(ft, tag, seen)...)
+		}
+	}
+	return names
+}
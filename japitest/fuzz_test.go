@@ -0,0 +1,33 @@
+package japitest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jarrettv/go-japi"
+)
+
+type fuzzAddress struct {
+	City string `json:"city" validate:"required"`
+	Zip  int    `json:"zip"`
+}
+
+type fuzzRequest struct {
+	Tenant  string        `header:"X-Tenant"`
+	Retries *int          `header:"X-Retries"`
+	ID      int64         `path:"id"`
+	Slug    string        `path:"slug"`
+	Limit   uint8         `query:"limit"`
+	Ratio   float64       `query:"ratio"`
+	Active  bool          `query:"active"`
+	Since   time.Time     `query:"since"`
+	Wait    time.Duration `query:"wait"`
+	Tags    []string      `query:"tags"`
+	Name    string        `json:"name"`
+	Address *fuzzAddress  `json:"address"`
+	Scores  []float32     `json:"scores"`
+}
+
+func FuzzRequest(f *testing.F) {
+	Fuzz[fuzzRequest](f, japi.WithDisallowUnknownFields(true), japi.WithMaxBodyBytes(1<<10))
+}
@@ -0,0 +1,245 @@
+// Package japitest replays request fixtures against an API and compares the
+// responses, including the problems and headers, to golden files. It also
+// fuzzes the decoding of request types.
+package japitest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// UpdateEnv is the environment variable that rewrites the golden files with
+// the responses when it is set e.g. JAPITEST_UPDATE=1 go test ./...
+const UpdateEnv = "JAPITEST_UPDATE"
+
+// Fixture is a recorded request.
+type Fixture struct {
+	// Name is the golden file name, the fixture file name when loaded.
+	Name   string            `json:"name"`
+	Method string            `json:"method"`
+	Path   string            `json:"path"`
+	Header map[string]string `json:"header,omitempty"`
+	Body   json.RawMessage   `json:"body,omitempty"`
+}
+
+// Option configures the golden tests.
+type Option func(*Golden)
+
+// WithDir stores the golden files in the dir instead of testdata/golden.
+func WithDir(dir string) Option {
+	return func(g *Golden) {
+		g.dir = dir
+	}
+}
+
+// WithHeaders sets the response headers in the golden files.
+func WithHeaders(names ...string) Option {
+	return func(g *Golden) {
+		g.headers = names
+	}
+}
+
+// RedactFields replaces the values of the json fields at any depth with
+// [redacted] e.g. ids and timestamps. The problem instance is always redacted.
+func RedactFields(names ...string) Option {
+	return func(g *Golden) {
+		for _, name := range names {
+			g.fields[name] = true
+		}
+	}
+}
+
+// Redact replaces the matches of the regular expression in the headers and
+// body with the replacement.
+func Redact(pattern, replacement string) Option {
+	re := regexp.MustCompile(pattern)
+	return func(g *Golden) {
+		g.redact = append(g.redact, redaction{re: re, replacement: replacement})
+	}
+}
+
+type redaction struct {
+	re          *regexp.Regexp
+	replacement string
+}
+
+// Golden compares the responses of the handler to golden files.
+type Golden struct {
+	handler http.Handler
+	dir     string
+	headers []string
+	fields  map[string]bool
+	redact  []redaction
+}
+
+// New creates the golden tests of the handler e.g. the API router.
+func New(h http.Handler, opts ...Option) *Golden {
+	g := &Golden{
+		handler: h,
+		dir:     filepath.Join("testdata", "golden"),
+		headers: []string{"Content-Type", "Location", "Cache-Control", "Allow", "Retry-After", "WWW-Authenticate"},
+		fields:  map[string]bool{},
+	}
+	for _, opt := range opts {
+		opt(g)
+	}
+	return g
+}
+
+// Run replays each fixture as a sub test and compares the response to the
+// name.golden file.
+func (g *Golden) Run(t *testing.T, fixtures ...Fixture) {
+	t.Helper()
+	for _, f := range fixtures {
+		f := f
+		if f.Method == "" {
+			f.Method = http.MethodGet
+		}
+		t.Run(f.Name, func(t *testing.T) {
+			g.check(t, f)
+		})
+	}
+}
+
+// RunDir replays the fixtures of the json files in the dir.
+func (g *Golden) RunDir(t *testing.T, dir string) {
+	t.Helper()
+	fixtures, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.Run(t, fixtures...)
+}
+
+// Load reads the fixtures of the json files in the dir sorted by name.
+func Load(dir string) ([]Fixture, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	fixtures := make([]Fixture, 0, len(files))
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		var f Fixture
+		if err := json.Unmarshal(data, &f); err != nil {
+			return nil, fmt.Errorf("japitest: %s: %w", file, err)
+		}
+		if f.Name == "" {
+			f.Name = strings.TrimSuffix(filepath.Base(file), ".json")
+		}
+		fixtures = append(fixtures, f)
+	}
+	return fixtures, nil
+}
+
+func (g *Golden) check(t *testing.T, f Fixture) {
+	t.Helper()
+	got := g.Snapshot(f)
+	file := filepath.Join(g.dir, f.Name+".golden")
+
+	if os.Getenv(UpdateEnv) != "" {
+		if err := os.MkdirAll(g.dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("japitest: %v, run with %s=1 to record it", err, UpdateEnv)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("japitest: %s %s does not match %s\n--- want\n%s\n--- got\n%s", f.Method, f.Path, file, want, got)
+	}
+}
+
+// Snapshot serves the fixture and returns the redacted status, headers and
+// indented body of the response.
+func (g *Golden) Snapshot(f Fixture) []byte {
+	method := f.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+	req := httptest.NewRequest(method, f.Path, bytes.NewReader(f.Body))
+	for k, v := range f.Header {
+		req.Header.Set(k, v)
+	}
+	if len(f.Body) > 0 && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	w := httptest.NewRecorder()
+	g.handler.ServeHTTP(w, req)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%d %s\n", w.Code, http.StatusText(w.Code))
+	for _, name := range g.headers {
+		for _, v := range w.Header().Values(name) {
+			fmt.Fprintf(&buf, "%s: %s\n", name, v)
+		}
+	}
+	buf.WriteByte('\n')
+	buf.Write(g.body(w.Body.Bytes()))
+
+	out := buf.Bytes()
+	for _, r := range g.redact {
+		out = r.re.ReplaceAll(out, []byte(r.replacement))
+	}
+	return out
+}
+
+// body indents json bodies with sorted keys and redacts the fields.
+func (g *Golden) body(data []byte) []byte {
+	var v any
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if len(bytes.TrimSpace(data)) == 0 || dec.Decode(&v) != nil {
+		return data
+	}
+
+	if p, ok := v.(map[string]any); ok && p["type"] != nil && p["title"] != nil && p["instance"] != nil {
+		p["instance"] = "[redacted]"
+	}
+	v = g.redactFields(v)
+
+	out, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return data
+	}
+	return append(out, '\n')
+}
+
+func (g *Golden) redactFields(v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		for k, fv := range t {
+			if g.fields[k] {
+				t[k] = "[redacted]"
+			} else {
+				t[k] = g.redactFields(fv)
+			}
+		}
+	case []any:
+		for i := range t {
+			t[i] = g.redactFields(t[i])
+		}
+	}
+	return v
+}
@@ -0,0 +1,71 @@
+package japi
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type localesKey struct{}
+
+// Locales returns the locales of the request Accept-Language header ordered by
+// preference e.g. [fr-CH fr en] or nil without the header.
+func Locales(ctx context.Context) []string {
+	locales, _ := ctx.Value(localesKey{}).([]string)
+	return locales
+}
+
+// withLocales parses the Accept-Language header before the middleware runs.
+func withLocales(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if locales := ParseAcceptLanguage(r.Header.Values("Accept-Language")...); len(locales) > 0 {
+			r = r.WithContext(context.WithValue(r.Context(), localesKey{}, locales))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ParseAcceptLanguage returns the language ranges of the Accept-Language
+// values ordered by quality. Ranges with the same quality keep their order,
+// the * range and ranges with a zero or invalid quality are left out.
+func ParseAcceptLanguage(values ...string) []string {
+	type weighted struct {
+		locale string
+		q      float64
+	}
+
+	var ranges []weighted
+	for _, v := range values {
+		for _, part := range strings.Split(v, ",") {
+			locale, params, _ := strings.Cut(part, ";")
+			locale = strings.TrimSpace(locale)
+			if locale == "" || locale == "*" {
+				continue
+			}
+
+			q := 1.0
+			if name, value, ok := strings.Cut(strings.TrimSpace(params), "="); ok && strings.TrimSpace(name) == "q" {
+				var err error
+				if q, err = strconv.ParseFloat(strings.TrimSpace(value), 64); err != nil || q > 1 {
+					continue
+				}
+			}
+			if q <= 0 {
+				continue
+			}
+			ranges = append(ranges, weighted{locale: locale, q: q})
+		}
+	}
+
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].q > ranges[j].q })
+	locales := make([]string, len(ranges))
+	for i, r := range ranges {
+		locales[i] = r.locale
+	}
+	if len(locales) == 0 {
+		return nil
+	}
+	return locales
+}
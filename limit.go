@@ -0,0 +1,39 @@
+package japi
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/jarrettv/go-japi/problem"
+)
+
+// limit wraps the handle to respond with a 503 problem when the semaphore is
+// full.
+func limit(ref *configRef, sem chan struct{}, next httprouter.Handle) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+		if !acquire(ref, sem, w, r) {
+			return
+		}
+		defer func() { <-sem }()
+		next(w, r, p)
+	}
+}
+
+// acquire takes a slot of the semaphore or responds with a 503 problem when
+// it is full.
+func acquire(ref *configRef, sem chan struct{}, w http.ResponseWriter, r *http.Request) bool {
+	select {
+	case sem <- struct{}{}:
+		return true
+	default:
+		c := ref.Load()
+		retry := c.RetryAfter
+		if retry <= 0 {
+			retry = time.Second
+		}
+		c.serveProblem(w, r, problem.Unavailable().WithRetryAfter(retry))
+		return false
+	}
+}
@@ -0,0 +1,135 @@
+package japi
+
+import (
+	"net/url"
+	"reflect"
+	"strings"
+
+	"github.com/jarrettv/go-japi/problem"
+)
+
+// ListQuery can be embedded in your request to decode the list conventions
+// ?filter[status]=active&sort=-created_at,name&fields=id,name. Restrict the
+// allowed fields with comma separated filter, sort and fields tags on the
+// embedded field, every field is allowed without a tag e.g.
+//
+//	japi.ListQuery `filter:"status,type" sort:"created_at,name"`
+type ListQuery struct {
+	Filters map[string]string `json:"-"`
+	Sort    []SortField       `json:"-"`
+	Fields  []string          `json:"-"`
+}
+
+// SortField is a field to sort by.
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// Filter returns the filter value of the field.
+func (lq ListQuery) Filter(field string) (string, bool) {
+	v, ok := lq.Filters[field]
+	return v, ok
+}
+
+var listQueryType = reflect.TypeOf(ListQuery{})
+
+// listDecoder decodes the ListQuery field of a request type.
+type listDecoder struct {
+	index  []int
+	filter map[string]bool
+	sort   map[string]bool
+	fields map[string]bool
+}
+
+// newListDecoder returns the decoder of the embedded ListQuery of the request
+// type or nil when it has none.
+func newListDecoder(t reflect.Type) *listDecoder {
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Type != listQueryType {
+			continue
+		}
+		return &listDecoder{
+			index:  f.Index,
+			filter: allowList(f.Tag, "filter"),
+			sort:   allowList(f.Tag, "sort"),
+			fields: allowList(f.Tag, "fields"),
+		}
+	}
+	return nil
+}
+
+func allowList(tag reflect.StructTag, key string) map[string]bool {
+	v, ok := tag.Lookup(key)
+	if !ok {
+		return nil
+	}
+	allowed := map[string]bool{}
+	for _, name := range strings.Split(v, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			allowed[name] = true
+		}
+	}
+	return allowed
+}
+
+// decode sets the ListQuery of the request from the query adding the errors
+// of fields that are not allowed.
+func (d *listDecoder) decode(query url.Values, v any, errs problem.Errors) {
+	lq := ListQuery{Filters: map[string]string{}}
+
+	for key, values := range query {
+		field, ok := strings.CutPrefix(key, "filter[")
+		if !ok || !strings.HasSuffix(field, "]") || len(values) == 0 {
+			continue
+		}
+		field = strings.TrimSuffix(field, "]")
+		if d.filter != nil && !d.filter[field] {
+			errs.Add(key, "is not a filter")
+			continue
+		}
+		lq.Filters[field] = values[len(values)-1]
+	}
+
+	for _, field := range splitList(query.Get("sort")) {
+		sf := SortField{Field: field}
+		if name, ok := strings.CutPrefix(field, "-"); ok {
+			sf = SortField{Field: name, Desc: true}
+		} else {
+			sf.Field = strings.TrimPrefix(field, "+")
+		}
+		if d.sort != nil && !d.sort[sf.Field] {
+			errs.Add("sort", sf.Field+" is not sortable")
+			continue
+		}
+		lq.Sort = append(lq.Sort, sf)
+	}
+
+	for _, field := range splitList(query.Get("fields")) {
+		if d.fields != nil && !d.fields[field] {
+			errs.Add("fields", field+" is not a field")
+			continue
+		}
+		lq.Fields = append(lq.Fields, field)
+	}
+
+	reflect.ValueOf(v).Elem().FieldByIndex(d.index).Set(reflect.ValueOf(lq))
+}
+
+func splitList(s string) []string {
+	var list []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
+}
@@ -0,0 +1,91 @@
+package japi
+
+import (
+	"mime"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/jarrettv/go-japi/problem"
+)
+
+var unsupportedMediaType = problem.Register("unsupported-media-type", http.StatusUnsupportedMediaType, "Unsupported media type").
+	Describe("The content type of the request body is not supported")
+
+// WithContentTypes restricts the content types of the route request bodies.
+// The default is the types of the registered decoders and the +json types.
+// Bodies without a content type are decoded as the first type.
+func WithContentTypes(contentTypes ...string) RouteOption {
+	return func(rt *Route) {
+		rt.contentTypes = contentTypes
+	}
+}
+
+// requestDecoder returns the decoder of the request content type or the 415
+// problem listing the supported types. The json types are decoded with
+// Config.JSONUnmarshal when it is set.
+func (h *handler[T, O]) requestDecoder(r *http.Request, config *Config) (RequestParser, *problem.Problem) {
+	var accepted []string
+	if h.route != nil {
+		accepted = h.route.contentTypes
+	}
+
+	ct := r.Header.Get("Content-Type")
+	if ct == "" {
+		if len(accepted) > 0 {
+			ct = accepted[0]
+		} else {
+			ct = JsonEncoding
+		}
+	}
+
+	mt, _, err := mime.ParseMediaType(ct)
+	if err == nil && (len(accepted) == 0 || hasToken(accepted, mt)) {
+		if config.JSONUnmarshal != nil && isJSONType(mt) {
+			return unmarshalJSON(config.JSONUnmarshal), nil
+		}
+		if dec, err := getDecoder(mt); err == nil {
+			return dec, nil
+		}
+		if strings.HasSuffix(mt, "+json") {
+			return decoders[JsonEncoding], nil
+		}
+	}
+
+	supported := supportedTypes(accepted)
+	p := unsupportedMediaType.New(ct + " is not supported, use " + strings.Join(supported, ", "))
+	p.WithHeader("Accept", strings.Join(supported, ", "))
+	if r.Method == http.MethodPatch {
+		p.WithHeader("Accept-Patch", strings.Join(supported, ", "))
+	}
+	return nil, p
+}
+
+// isJSONType reports whether the media type is decoded as json.
+func isJSONType(mt string) bool {
+	return mt == JsonEncoding || mt == MergePatchEncoding || mt == JsonPatchEncoding ||
+		strings.HasSuffix(mt, "+json")
+}
+
+// supportedTypes returns the accepted types that can be decoded, all the types
+// of the registered decoders without accepted types.
+func supportedTypes(accepted []string) []string {
+	var types []string
+	if len(accepted) == 0 {
+		for mt := range decoders {
+			types = append(types, mt)
+		}
+		for alias := range decoderAliases {
+			types = append(types, alias)
+		}
+		sort.Strings(types)
+		return types
+	}
+
+	for _, mt := range accepted {
+		if _, err := getDecoder(mt); err == nil || strings.HasSuffix(mt, "+json") {
+			types = append(types, mt)
+		}
+	}
+	return types
+}
@@ -0,0 +1,55 @@
+package metrics
+
+import (
+	"time"
+)
+
+// Recorder records request metrics for the matched routes.
+type Recorder interface {
+	// InFlight adjusts the number of requests in flight for the route.
+	InFlight(route string, delta int)
+	// Request records a finished request with its status and latency.
+	Request(route, method string, status int, duration time.Duration)
+	// Problem records a problem details response served for the route.
+	Problem(route string, status int)
+}
+
+// TenantRecorder is implemented by recorders that count the requests of each
+// tenant when japi tenancy is enabled.
+type TenantRecorder interface {
+	// TenantRequest records a finished request of the tenant.
+	TenantRequest(tenant, route string, status int)
+}
+
+// Multi creates a Recorder that records to all the recorders.
+func Multi(recorders ...Recorder) Recorder {
+	return multi(recorders)
+}
+
+type multi []Recorder
+
+func (m multi) InFlight(route string, delta int) {
+	for _, r := range m {
+		r.InFlight(route, delta)
+	}
+}
+
+func (m multi) Request(route, method string, status int, duration time.Duration) {
+	for _, r := range m {
+		r.Request(route, method, status, duration)
+	}
+}
+
+func (m multi) Problem(route string, status int) {
+	for _, r := range m {
+		r.Problem(route, status)
+	}
+}
+
+func (m multi) TenantRequest(tenant, route string, status int) {
+	for _, r := range m {
+		if tr, ok := r.(TenantRecorder); ok {
+			tr.TenantRequest(tenant, route, status)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package otel
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+
+	"github.com/jarrettv/go-japi/metrics"
+)
+
+// Recorder is a metrics.Recorder that records to OpenTelemetry meters.
+type Recorder struct {
+	requests metric.Int64Counter
+	duration metric.Float64Histogram
+	inFlight metric.Int64UpDownCounter
+	problems metric.Int64Counter
+	tenants  metric.Int64Counter
+}
+
+var (
+	_ metrics.Recorder       = (*Recorder)(nil)
+	_ metrics.TenantRecorder = (*Recorder)(nil)
+)
+
+// New creates an OpenTelemetry recorder using the meter.
+func New(meter metric.Meter) (*Recorder, error) {
+	requests, err := meter.Int64Counter("http.server.requests",
+		metric.WithDescription("Number of HTTP requests handled."))
+	if err != nil {
+		return nil, err
+	}
+
+	duration, err := meter.Float64Histogram("http.server.request.duration",
+		metric.WithDescription("Latency of HTTP requests handled."),
+		metric.WithUnit("s"))
+	if err != nil {
+		return nil, err
+	}
+
+	inFlight, err := meter.Int64UpDownCounter("http.server.active_requests",
+		metric.WithDescription("Number of HTTP requests currently being handled."))
+	if err != nil {
+		return nil, err
+	}
+
+	problems, err := meter.Int64Counter("http.server.problems",
+		metric.WithDescription("Number of problem details responses served."))
+	if err != nil {
+		return nil, err
+	}
+
+	tenants, err := meter.Int64Counter("http.server.tenant.requests",
+		metric.WithDescription("Number of HTTP requests handled by tenant."))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Recorder{
+		requests: requests,
+		duration: duration,
+		inFlight: inFlight,
+		problems: problems,
+		tenants:  tenants,
+	}, nil
+}
+
+// InFlight implements metrics.Recorder.
+func (o *Recorder) InFlight(route string, delta int) {
+	o.inFlight.Add(context.Background(), int64(delta),
+		metric.WithAttributes(attribute.String("http.route", route)))
+}
+
+// Request implements metrics.Recorder.
+func (o *Recorder) Request(route, method string, status int, duration time.Duration) {
+	attrs := metric.WithAttributes(
+		attribute.String("http.route", route),
+		attribute.String("http.request.method", method),
+		attribute.Int("http.response.status_code", status),
+	)
+	o.requests.Add(context.Background(), 1, attrs)
+	o.duration.Record(context.Background(), duration.Seconds(), attrs)
+}
+
+// Problem implements metrics.Recorder.
+func (o *Recorder) Problem(route string, status int) {
+	o.problems.Add(context.Background(), 1, metric.WithAttributes(
+		attribute.String("http.route", route),
+		attribute.Int("http.response.status_code", status),
+	))
+}
+
+// TenantRequest implements metrics.TenantRecorder.
+func (o *Recorder) TenantRequest(tenant, route string, status int) {
+	o.tenants.Add(context.Background(), 1, metric.WithAttributes(
+		attribute.String("tenant", tenant),
+		attribute.String("http.route", route),
+		attribute.Int("http.response.status_code", status),
+	))
+}
@@ -0,0 +1,97 @@
+package prometheus
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/jarrettv/go-japi/metrics"
+)
+
+// Recorder is a metrics.Recorder that is also a prometheus.Collector.
+type Recorder struct {
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+	inFlight *prometheus.GaugeVec
+	problems *prometheus.CounterVec
+	tenants  *prometheus.CounterVec
+}
+
+var (
+	_ metrics.Recorder       = (*Recorder)(nil)
+	_ metrics.TenantRecorder = (*Recorder)(nil)
+)
+
+// New creates a Prometheus recorder with the given namespace. Register it
+// with prometheus.MustRegister to expose the metrics.
+func New(namespace string) *Recorder {
+	return &Recorder{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "Number of HTTP requests handled.",
+		}, []string{"route", "method", "status"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Latency of HTTP requests handled.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"route", "method", "status"}),
+		inFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "http_requests_in_flight",
+			Help:      "Number of HTTP requests currently being handled.",
+		}, []string{"route"}),
+		problems: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_problems_total",
+			Help:      "Number of problem details responses served.",
+		}, []string{"route", "status"}),
+		tenants: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_tenant_requests_total",
+			Help:      "Number of HTTP requests handled by tenant.",
+		}, []string{"tenant", "route", "status"}),
+	}
+}
+
+// InFlight implements metrics.Recorder.
+func (p *Recorder) InFlight(route string, delta int) {
+	p.inFlight.WithLabelValues(route).Add(float64(delta))
+}
+
+// Request implements metrics.Recorder.
+func (p *Recorder) Request(route, method string, status int, duration time.Duration) {
+	code := strconv.Itoa(status)
+	p.requests.WithLabelValues(route, method, code).Inc()
+	p.duration.WithLabelValues(route, method, code).Observe(duration.Seconds())
+}
+
+// Problem implements metrics.Recorder.
+func (p *Recorder) Problem(route string, status int) {
+	p.problems.WithLabelValues(route, strconv.Itoa(status)).Inc()
+}
+
+// TenantRequest implements metrics.TenantRecorder.
+func (p *Recorder) TenantRequest(tenant, route string, status int) {
+	p.tenants.WithLabelValues(tenant, route, strconv.Itoa(status)).Inc()
+}
+
+// Describe implements prometheus.Collector.
+func (p *Recorder) Describe(ch chan<- *prometheus.Desc) {
+	p.requests.Describe(ch)
+	p.duration.Describe(ch)
+	p.inFlight.Describe(ch)
+	p.problems.Describe(ch)
+	p.tenants.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (p *Recorder) Collect(ch chan<- prometheus.Metric) {
+	p.requests.Collect(ch)
+	p.duration.Collect(ch)
+	p.inFlight.Collect(ch)
+	p.problems.Collect(ch)
+	p.tenants.Collect(ch)
+}
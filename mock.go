@@ -0,0 +1,167 @@
+package japi
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/goccy/go-json"
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/jarrettv/go-japi/problem"
+)
+
+const exampleTag = "example"
+
+var examples = map[reflect.Type]any{}
+
+// RegisterExample registers the example value of its type for the mock server.
+// It is used instead of the example tags of the type.
+func RegisterExample(v any) {
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	examples[t] = v
+}
+
+// MockFrom builds a handler that serves an example response for every route of
+// the api. The examples are the WithExample responses, the registered examples
+// or are built from the example tags of the response fields e.g. `example:"Bob"`
+// or `example:"[1,2]"`. Routes of regular handlers without an example respond
+// with 501 problems.
+func MockFrom(api *API) http.Handler {
+	config := api.config
+	router := httprouter.New()
+	versions := map[string]*versionedRoute{}
+
+	for _, rt := range api.routes {
+		hh := mockHandle(config, rt)
+		if rt.Version == "" {
+			router.Handle(rt.Method, rt.Path, hh)
+			continue
+		}
+
+		// the versions of a route share the path and are negotiated like the api
+		key := rt.Method + " " + rt.Path
+		vr, ok := versions[key]
+		if !ok {
+			vr = &versionedRoute{config: config, handles: map[string]httprouter.Handle{}}
+			versions[key] = vr
+			router.Handle(rt.Method, rt.Path, vr.handle)
+		}
+		vr.add(rt.Version, hh)
+
+		if config.Load().Versioning.PathPrefix {
+			router.Handle(rt.Method, "/"+rt.Version+rt.Path, serveVersion(config, rt.Version, hh))
+		}
+	}
+	return router
+}
+
+// mockHandle returns the handle serving the example response of the route.
+func mockHandle(config *configRef, rt *Route) httprouter.Handle {
+	_, res := rt.Example()
+	if rt.response == nil && res == nil {
+		return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+			config.Load().serveProblem(w, r, problem.Status(http.StatusNotImplemented))
+		}
+	}
+
+	if res == nil {
+		res = exampleOf(rt.response).Interface()
+	}
+	encodeHeader := newHeaderEncoder(reflect.TypeOf(res))
+	return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		w.Header().Set("Content-Type", JsonEncoding+"; charset=utf-8")
+
+		body := res
+		if encodeHeader != nil {
+			body = encodeHeader(w.Header(), res, config.Load().JSONMarshal)
+		}
+
+		status := rt.status
+		if sc, ok := res.(StatusCoder); ok {
+			status = sc.StatusCode()
+		} else if status == 0 && r.Method == http.MethodPost {
+			status = http.StatusCreated
+		}
+		if status == http.StatusNoContent {
+			w.Header().Del("Content-Type")
+			w.WriteHeader(status)
+			return
+		}
+		if status != 0 {
+			w.WriteHeader(status)
+		}
+
+		c := config.Load()
+		opts := c.Encode
+		opts.marshal = c.JSONMarshal
+		if e := encodeJSON(w, r, body, opts); e != nil {
+			http.Error(w, e.Error(), http.StatusInternalServerError)
+		}
+	}
+}
+
+// exampleOf returns the example value of the type.
+func exampleOf(t reflect.Type) reflect.Value {
+	return exampleValue(t, map[reflect.Type]bool{})
+}
+
+func exampleValue(t reflect.Type, seen map[reflect.Type]bool) reflect.Value {
+	if ex, ok := examples[t]; ok {
+		v := reflect.New(t).Elem()
+		ev := reflect.ValueOf(ex)
+		for ev.Kind() == reflect.Pointer {
+			ev = ev.Elem()
+		}
+		v.Set(ev)
+		return v
+	}
+
+	v := reflect.New(t).Elem()
+	switch t.Kind() {
+	case reflect.Pointer:
+		if !seen[t.Elem()] {
+			v.Set(exampleValue(t.Elem(), seen).Addr())
+		}
+	case reflect.Slice:
+		if !seen[t.Elem()] {
+			v.Set(reflect.Append(v, exampleValue(t.Elem(), seen)))
+		}
+	case reflect.Struct:
+		seen[t] = true // recursive types end with nil
+		defer delete(seen, t)
+
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if f.PkgPath != "" {
+				continue
+			}
+
+			if tag, ok := f.Tag.Lookup(exampleTag); ok {
+				setExample(v.Field(i), tag)
+			} else if !strings.HasPrefix(f.Tag.Get("json"), "-") {
+				v.Field(i).Set(exampleValue(f.Type, seen))
+			}
+		}
+	}
+	return v
+}
+
+// setExample sets the example tag value of the field. Strings are set as is
+// and the other kinds are decoded from json.
+func setExample(v reflect.Value, tag string) {
+	if v.Kind() == reflect.String {
+		v.SetString(tag)
+		return
+	}
+	if v.Kind() == reflect.Pointer && v.Type().Elem().Kind() == reflect.String {
+		s := reflect.New(v.Type().Elem())
+		s.Elem().SetString(tag)
+		v.Set(s)
+		return
+	}
+	_ = json.Unmarshal([]byte(tag), v.Addr().Interface())
+}
@@ -0,0 +1,324 @@
+package japi
+
+import (
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-json"
+
+	"github.com/jarrettv/go-japi/problem"
+)
+
+var (
+	rawJSONType         = reflect.TypeOf(json.RawMessage{})
+	fileType            = reflect.TypeOf(File{})
+	locationerType      = reflect.TypeOf((*Locationer)(nil)).Elem()
+	routeLocationerType = reflect.TypeOf((*RouteLocationer)(nil)).Elem()
+	timeType            = reflect.TypeOf(time.Time{})
+)
+
+// routeExample is the example request and response of a route.
+type routeExample struct {
+	req any
+	res any
+}
+
+// WithDescription describes the route in the OpenAPI document.
+func WithDescription(description string) RouteOption {
+	return func(rt *Route) {
+		rt.Description = description
+	}
+}
+
+// WithExample sets the example request and response of the route for the
+// OpenAPI document and the mock server. Either can be nil.
+func WithExample(req, res any) RouteOption {
+	return func(rt *Route) {
+		rt.example = &routeExample{req: req, res: res}
+	}
+}
+
+// Example returns the example request and response of the route.
+func (rt *Route) Example() (req, res any) {
+	if rt.example == nil {
+		return nil, nil
+	}
+	return rt.example.req, rt.example.res
+}
+
+// OpenAPI returns the OpenAPI 3.1 document of the registered routes. The
+// schemas are built from the request and response types of the handlers and
+// the problems are described by the default response.
+func (r *API) OpenAPI(title, version string) ([]byte, error) {
+	paths := map[string]map[string]any{}
+	for _, rt := range r.routes {
+		path := openAPIPath(rt.Path)
+		if paths[path] == nil {
+			paths[path] = map[string]any{}
+		}
+		paths[path][strings.ToLower(rt.Method)] = rt.operation()
+	}
+
+	return json.Marshal(map[string]any{
+		"openapi": "3.1.0",
+		"info":    map[string]any{"title": title, "version": version},
+		"paths":   paths,
+		"components": map[string]any{
+			"schemas": map[string]any{"Problem": schemaOf(reflect.TypeOf(problem.Problem{}))},
+		},
+	})
+}
+
+// openAPIPath converts the :name and *name params to {name}.
+func openAPIPath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, seg := range segments {
+		if isWildcard(seg) {
+			segments[i] = "{" + seg[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+func (rt *Route) operation() map[string]any {
+	reqEx, resEx := rt.Example()
+	op := map[string]any{}
+	if rt.Name != "" {
+		op["operationId"] = rt.Name
+	}
+	if rt.Description != "" {
+		op["description"] = rt.Description
+	}
+	if rt.deprecation != nil {
+		op["deprecated"] = true
+		if rt.deprecation.docURL != "" {
+			op["externalDocs"] = map[string]any{"url": rt.deprecation.docURL}
+		}
+	}
+
+	responses := map[string]any{
+		"default": map[string]any{
+			"description": "Problem",
+			"content": map[string]any{
+				problem.ContentType: map[string]any{"schema": map[string]any{"$ref": "#/components/schemas/Problem"}},
+			},
+		},
+	}
+	op["responses"] = responses
+
+	if rt.request != nil {
+		if params := parameters(rt.request); len(params) > 0 {
+			op["parameters"] = params
+		}
+		if rt.Method != http.MethodGet && rt.Method != http.MethodDelete {
+			if body := bodySchema(rt.request); body != nil {
+				op["requestBody"] = map[string]any{
+					"content": map[string]any{mediaTypeOf(rt.request): mediaType(body, reqEx)},
+				}
+			}
+		}
+	}
+
+	status := "200"
+	if rt.Method == http.MethodPost && rt.response != nil && (rt.response.Implements(locationerType) ||
+		rt.response.Implements(routeLocationerType)) {
+		status = "201"
+	}
+	if rt.status != 0 {
+		status = strconv.Itoa(rt.status)
+	}
+	res := map[string]any{"description": http.StatusText(http.StatusOK)}
+	if rt.status != 0 {
+		res["description"] = http.StatusText(rt.status)
+	}
+	switch {
+	case rt.status == http.StatusNoContent:
+	case rt.response != nil:
+		if body := bodySchema(rt.response); body != nil {
+			res["content"] = map[string]any{mediaTypeOf(rt.response): mediaType(body, resEx)}
+		}
+	case resEx != nil:
+		res["content"] = map[string]any{JsonEncoding: map[string]any{"example": resEx}}
+	}
+	responses[status] = res
+	return op
+}
+
+func mediaType(schema map[string]any, example any) map[string]any {
+	mt := map[string]any{"schema": schema}
+	if example != nil {
+		mt["example"] = example
+	}
+	return mt
+}
+
+func mediaTypeOf(t reflect.Type) string {
+	t = derefType(t)
+	if t == fileType || t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 && t != rawJSONType {
+		return "application/octet-stream"
+	}
+	return JsonEncoding
+}
+
+// parameters returns the header, path and query params of the request type.
+func parameters(t reflect.Type) []map[string]any {
+	t = derefType(t)
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var params []map[string]any
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		for _, in := range []string{headerTag, pathTag, queryTag} {
+			tag, ok := f.Tag.Lookup(in)
+			if !ok {
+				continue
+			}
+			name, _, _ := strings.Cut(tag, ",")
+			params = append(params, map[string]any{
+				"name":     name,
+				"in":       in,
+				"required": in == pathTag || hasRequired(f),
+				"schema":   schemaOf(f.Type),
+			})
+		}
+		if f.Anonymous && derefType(f.Type).Kind() == reflect.Struct {
+			params = append(params, parameters(f.Type)...)
+		}
+	}
+	return params
+}
+
+// bodySchema returns the schema of the json body of the type or nil when it
+// has no body fields.
+func bodySchema(t reflect.Type) map[string]any {
+	t = derefType(t)
+	if t == fileType || t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 && t != rawJSONType {
+		return map[string]any{"type": "string", "format": "binary"}
+	}
+	s := schemaOf(t)
+	if props, ok := s["properties"].(map[string]any); ok && len(props) == 0 && t.Kind() == reflect.Struct {
+		return nil
+	}
+	return s
+}
+
+// schemaOf returns the json schema of the type.
+func schemaOf(t reflect.Type) map[string]any {
+	return schemaValue(t, map[reflect.Type]bool{})
+}
+
+func schemaValue(t reflect.Type, seen map[reflect.Type]bool) map[string]any {
+	t = derefType(t)
+	switch {
+	case t == timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case t == rawJSONType:
+		return map[string]any{}
+	case t.Kind() == reflect.Struct && reflect.PointerTo(t).Implements(optionalType):
+		if f, ok := t.FieldByName("Value"); ok {
+			return schemaValue(f.Type, seen)
+		}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]any{"type": "string", "format": "byte"}
+		}
+		return map[string]any{"type": "array", "items": schemaValue(t.Elem(), seen)}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": schemaValue(t.Elem(), seen)}
+	case reflect.Struct:
+		if seen[t] {
+			return map[string]any{"type": "object"} // recursive types are not expanded
+		}
+		seen[t] = true
+		defer delete(seen, t)
+
+		props, required := map[string]any{}, []string{}
+		addProperties(t, seen, props, &required)
+		s := map[string]any{"type": "object", "properties": props}
+		if len(required) > 0 {
+			s["required"] = required
+		}
+		return s
+	}
+	return map[string]any{}
+}
+
+// addProperties adds the json fields of the struct and its embedded structs.
+// The header, path and query fields are left out unless they have a json tag.
+func addProperties(t reflect.Type, seen map[reflect.Type]bool, props map[string]any, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" && !f.Anonymous {
+			continue
+		}
+
+		tag, hasJSON := f.Tag.Lookup("json")
+		name, _, _ := strings.Cut(tag, ",")
+		if name == "-" {
+			continue
+		}
+		if !hasJSON && isParam(f) {
+			continue
+		}
+		if f.Anonymous && name == "" && derefType(f.Type).Kind() == reflect.Struct {
+			addProperties(derefType(f.Type), seen, props, required)
+			continue
+		}
+		if f.PkgPath != "" {
+			continue
+		}
+
+		if name == "" {
+			name = f.Name
+		}
+		props[name] = schemaValue(f.Type, seen)
+		if hasRequired(f) {
+			*required = append(*required, name)
+		}
+	}
+}
+
+func isParam(f reflect.StructField) bool {
+	for _, tag := range []string{headerTag, pathTag, queryTag} {
+		if _, ok := f.Tag.Lookup(tag); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func hasRequired(f reflect.StructField) bool {
+	for _, rule := range strings.Split(f.Tag.Get(validateTag), ",") {
+		if rule == "required" {
+			return true
+		}
+	}
+	return false
+}
+
+func derefType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
@@ -0,0 +1,60 @@
+package japi
+
+import (
+	"github.com/goccy/go-json"
+)
+
+// Optional is a request value that tracks whether it was present. Use it to
+// tell the difference between a zero value and a value that was not sent.
+// A json null is present with the zero value.
+type Optional[T any] struct {
+	Value   T
+	Present bool
+}
+
+// Some creates a present Optional with the value.
+func Some[T any](v T) Optional[T] {
+	return Optional[T]{Value: v, Present: true}
+}
+
+// Get returns the value and whether it was present.
+func (o Optional[T]) Get() (T, bool) {
+	return o.Value, o.Present
+}
+
+// Or returns the value if present otherwise the default.
+func (o Optional[T]) Or(def T) T {
+	if o.Present {
+		return o.Value
+	}
+	return def
+}
+
+// OptionalValue implements decoder.Optional.
+func (o *Optional[T]) OptionalValue() any {
+	return &o.Value
+}
+
+// SetPresent implements decoder.Optional.
+func (o *Optional[T]) SetPresent() {
+	o.Present = true
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (o *Optional[T]) UnmarshalJSON(data []byte) error {
+	o.Present = true
+	if string(data) == "null" {
+		var zero T
+		o.Value = zero
+		return nil
+	}
+	return json.Unmarshal(data, &o.Value)
+}
+
+// MarshalJSON implements json.Marshaler.
+func (o Optional[T]) MarshalJSON() ([]byte, error) {
+	if !o.Present {
+		return []byte("null"), nil
+	}
+	return json.Marshal(o.Value)
+}
@@ -0,0 +1,78 @@
+package otel
+
+import (
+	"context"
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/jarrettv/go-japi"
+	"github.com/jarrettv/go-japi/problem"
+)
+
+const instrumentationName = "github.com/jarrettv/go-japi"
+
+// Tracer is a japi.Tracer backed by OpenTelemetry.
+type Tracer struct {
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+}
+
+// NewTracer creates a japi.Tracer using the global tracer provider and propagator.
+func NewTracer() *Tracer {
+	return NewTracerWith(otel.GetTracerProvider(), otel.GetTextMapPropagator())
+}
+
+// NewTracerWith creates a japi.Tracer using the tracer provider and propagator.
+func NewTracerWith(tp trace.TracerProvider, prop propagation.TextMapPropagator) *Tracer {
+	return &Tracer{
+		tracer:     tp.Tracer(instrumentationName),
+		propagator: prop,
+	}
+}
+
+// Start implements japi.Tracer.
+func (t *Tracer) Start(r *http.Request, route string) (context.Context, japi.Span) {
+	ctx := t.propagator.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+	ctx, span := t.tracer.Start(ctx, r.Method+" "+route,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			attribute.String("http.request.method", r.Method),
+			attribute.String("http.route", route),
+			attribute.String("url.path", r.URL.Path),
+		),
+	)
+
+	return ctx, &otelSpan{span: span}
+}
+
+type otelSpan struct {
+	span    trace.Span
+	problem bool
+}
+
+func (s *otelSpan) Event(name string) {
+	s.span.AddEvent(name)
+}
+
+func (s *otelSpan) Problem(p *problem.Problem) {
+	s.span.SetAttributes(attribute.String("problem.type", p.Type))
+	if p.Status >= http.StatusInternalServerError {
+		s.span.SetStatus(codes.Error, p.Error())
+		s.problem = true
+	}
+}
+
+func (s *otelSpan) End(status int) {
+	s.span.SetAttributes(attribute.Int("http.response.status_code", status))
+	if status >= http.StatusInternalServerError && !s.problem {
+		s.span.SetStatus(codes.Error, http.StatusText(status))
+	}
+	s.span.End()
+}
+
+var _ japi.Tracer = (*Tracer)(nil)
@@ -0,0 +1,327 @@
+package japi
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/goccy/go-json"
+)
+
+const resourceTag = "resource"
+
+// Output reshapes the response body into a media type document.
+type Output struct {
+	ContentType string
+	Shape       func(r *http.Request, body any) (any, error)
+}
+
+var (
+	// JSONAPI reshapes resources into JSON:API documents. Tag the id field with
+	// resource:"id,<type>" and related resources with resource:"rel".
+	JSONAPI = Output{ContentType: "application/vnd.api+json", Shape: toJSONAPI}
+	// HAL reshapes resources into HAL documents with self links and embedded
+	// related resources using the same tags as JSONAPI.
+	HAL = Output{ContentType: "application/hal+json", Shape: toHAL}
+)
+
+// Linker allows you to add links to JSONAPI and HAL resources.
+type Linker interface {
+	Links() map[string]string
+}
+
+// WithOutput reshapes the route responses with the output.
+func WithOutput(o Output) RouteOption {
+	return func(rt *Route) {
+		rt.output = &o
+	}
+}
+
+// output returns the route output or the config output the request accepts.
+func (h *handler[T, O]) output(config *Config, r *http.Request) *Output {
+	if h.route != nil && h.route.output != nil {
+		return h.route.output
+	}
+
+	for i := range config.Outputs {
+		if accepts(r, config.Outputs[i].ContentType) {
+			return &config.Outputs[i]
+		}
+	}
+	return nil
+}
+
+// accepts reports whether the Accept header has the media type.
+func accepts(r *http.Request, mediaType string) bool {
+	for _, accept := range r.Header.Values("Accept") {
+		for _, part := range strings.Split(accept, ",") {
+			if mt, _, err := mime.ParseMediaType(part); err == nil && mt == mediaType {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// resource is a struct value reshaped by the outputs.
+type resource struct {
+	typ     string
+	id      string
+	idName  string
+	idValue any
+	attrs   map[string]any
+	rels    map[string][]*resource
+	many    map[string]bool
+	links   map[string]string
+}
+
+// newResource returns the resource of the value or nil when the value has no
+// resource id tag.
+func newResource(v reflect.Value) (*resource, error) {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil, nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil, nil
+	}
+
+	res := &resource{rels: map[string][]*resource{}, many: map[string]bool{}}
+	remove := []string{}
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		if _, ok := f.Tag.Lookup(headerTag); ok {
+			if _, ok := f.Tag.Lookup("json"); !ok {
+				remove = append(remove, f.Name) // only sent as a header
+			}
+		}
+		tag, ok := f.Tag.Lookup(resourceTag)
+		if !ok {
+			continue
+		}
+
+		name := jsonName(f)
+		kind, typ, _ := strings.Cut(tag, ",")
+		switch kind {
+		case "id":
+			res.typ, res.id = typ, fmt.Sprint(v.Field(i).Interface())
+			res.idName, res.idValue = name, v.Field(i).Interface()
+		case "rel":
+			fv := v.Field(i)
+			if fv.Kind() == reflect.Slice {
+				res.many[name] = true
+				res.rels[name] = []*resource{}
+				for j := 0; j < fv.Len(); j++ {
+					rel, err := newResource(fv.Index(j))
+					if err != nil {
+						return nil, err
+					} else if rel != nil {
+						res.rels[name] = append(res.rels[name], rel)
+					}
+				}
+			} else if rel, err := newResource(fv); err != nil {
+				return nil, err
+			} else if rel != nil {
+				res.rels[name] = []*resource{rel}
+			}
+		}
+		remove = append(remove, name)
+	}
+
+	if res.typ == "" {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(v.Interface())
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &res.attrs); err != nil {
+		return nil, err
+	}
+	for _, name := range remove {
+		delete(res.attrs, name)
+	}
+
+	if l, ok := v.Interface().(Linker); ok {
+		res.links = l.Links()
+	} else if v.CanAddr() {
+		if l, ok := v.Addr().Interface().(Linker); ok {
+			res.links = l.Links()
+		}
+	}
+
+	return res, nil
+}
+
+// resources returns the resources of the body and whether it is a collection.
+func resources(body any) ([]*resource, bool, error) {
+	v := reflect.ValueOf(unwrapBody(body))
+	for v.Kind() == reflect.Pointer && !v.IsNil() {
+		v = v.Elem()
+	}
+
+	if v.Kind() == reflect.Slice {
+		list := make([]*resource, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			res, err := newResource(v.Index(i))
+			if err != nil || res == nil {
+				return nil, false, err
+			}
+			list = append(list, res)
+		}
+		return list, true, nil
+	}
+
+	res, err := newResource(v)
+	if err != nil || res == nil {
+		return nil, false, err
+	}
+	return []*resource{res}, false, nil
+}
+
+func jsonName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	if name == "" {
+		return f.Name
+	}
+	return name
+}
+
+func toJSONAPI(r *http.Request, body any) (any, error) {
+	list, many, err := resources(body)
+	if err != nil {
+		return nil, err
+	}
+	if list == nil {
+		return map[string]any{"meta": body}, nil
+	}
+
+	included := []any{}
+	seen := map[string]bool{}
+	var include func(res *resource)
+	include = func(res *resource) {
+		for _, rels := range res.rels {
+			for _, rel := range rels {
+				if key := rel.typ + "/" + rel.id; !seen[key] {
+					seen[key] = true
+					included = append(included, jsonAPIResource(rel))
+					include(rel)
+				}
+			}
+		}
+	}
+
+	data := make([]any, len(list))
+	for i, res := range list {
+		seen[res.typ+"/"+res.id] = true
+		data[i] = jsonAPIResource(res)
+	}
+	for _, res := range list {
+		include(res)
+	}
+
+	doc := map[string]any{"data": data}
+	if !many {
+		doc["data"] = data[0]
+	}
+	if len(included) > 0 {
+		doc["included"] = included
+	}
+	return doc, nil
+}
+
+func jsonAPIResource(res *resource) map[string]any {
+	doc := map[string]any{"type": res.typ, "id": res.id}
+	if len(res.attrs) > 0 {
+		doc["attributes"] = res.attrs
+	}
+
+	if len(res.rels) > 0 {
+		rels := map[string]any{}
+		for name, list := range res.rels {
+			ids := make([]any, len(list))
+			for i, rel := range list {
+				ids[i] = map[string]string{"type": rel.typ, "id": rel.id}
+			}
+			if res.many[name] {
+				rels[name] = map[string]any{"data": ids}
+			} else if len(ids) > 0 {
+				rels[name] = map[string]any{"data": ids[0]}
+			}
+		}
+		doc["relationships"] = rels
+	}
+
+	if len(res.links) > 0 {
+		doc["links"] = res.links
+	}
+	return doc
+}
+
+func toHAL(r *http.Request, body any) (any, error) {
+	list, many, err := resources(body)
+	if err != nil {
+		return nil, err
+	}
+	if list == nil {
+		return body, nil
+	}
+
+	if !many {
+		return halResource(list[0]), nil
+	}
+
+	items := make([]any, len(list))
+	for i, res := range list {
+		items[i] = halResource(res)
+	}
+
+	typ := "items"
+	if len(list) > 0 {
+		typ = list[0].typ
+	}
+	return map[string]any{
+		"_links":    map[string]any{"self": map[string]string{"href": r.URL.RequestURI()}},
+		"_embedded": map[string]any{typ: items},
+	}, nil
+}
+
+func halResource(res *resource) map[string]any {
+	doc := map[string]any{res.idName: res.idValue}
+	for k, v := range res.attrs {
+		doc[k] = v
+	}
+
+	links := map[string]any{"self": map[string]string{"href": "/" + res.typ + "/" + res.id}}
+	for name, href := range res.links {
+		links[name] = map[string]string{"href": href}
+	}
+	doc["_links"] = links
+
+	if len(res.rels) > 0 {
+		embedded := map[string]any{}
+		for name, list := range res.rels {
+			items := make([]any, len(list))
+			for i, rel := range list {
+				items[i] = halResource(rel)
+			}
+			if res.many[name] {
+				embedded[name] = items
+			} else if len(items) > 0 {
+				embedded[name] = items[0]
+			}
+		}
+		doc["_embedded"] = embedded
+	}
+	return doc
+}
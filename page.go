@@ -0,0 +1,154 @@
+package japi
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+var (
+	// DefaultPerPage is the page size when per_page is not sent.
+	DefaultPerPage = 20
+	// MaxPerPage is the largest page size allowed.
+	MaxPerPage = 100
+)
+
+// PageRequest can be embedded in your request to decode pagination values.
+type PageRequest struct {
+	Page    int    `query:"page"`
+	PerPage int    `query:"per_page"`
+	Cursor  string `query:"cursor"`
+	url     *url.URL
+}
+
+// AfterDecode implements AfterDecoder to apply the pagination limits.
+func (pr *PageRequest) AfterDecode(r *http.Request) error {
+	pr.url = requestURL(r)
+	if pr.Page < 1 {
+		pr.Page = 1
+	}
+	if pr.PerPage <= 0 {
+		pr.PerPage = DefaultPerPage
+	}
+	if pr.PerPage > MaxPerPage {
+		pr.PerPage = MaxPerPage
+	}
+	return nil
+}
+
+// Offset returns the number of items to skip for the page.
+func (pr PageRequest) Offset() int {
+	if pr.Page < 1 {
+		return 0
+	}
+	return (pr.Page - 1) * pr.PerPage
+}
+
+// Page is a response with a page of items. It adds the RFC 5988 Link header
+// with first, prev, next & last page links.
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	Page       int    `json:"page,omitempty"`
+	PerPage    int    `json:"per_page"`
+	Total      int    `json:"total,omitempty"`
+	TotalPages int    `json:"total_pages,omitempty"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	url        *url.URL
+	hasTotal   bool
+}
+
+// NewPage creates a page of items for the request. Use a negative total
+// when the total is not known.
+func NewPage[T any](req PageRequest, items []T, total int) *Page[T] {
+	p := &Page[T]{
+		Items:   items,
+		Page:    req.Page,
+		PerPage: req.PerPage,
+		url:     req.url,
+	}
+
+	if total >= 0 {
+		p.hasTotal = true
+		p.Total = total
+		if req.PerPage > 0 {
+			p.TotalPages = (total + req.PerPage - 1) / req.PerPage
+		}
+	}
+
+	return p
+}
+
+// NewCursorPage creates a page of items for the request using cursors. Use
+// an empty next cursor for the last page.
+func NewCursorPage[T any](req PageRequest, items []T, nextCursor string) *Page[T] {
+	return &Page[T]{
+		Items:      items,
+		PerPage:    req.PerPage,
+		NextCursor: nextCursor,
+		url:        req.url,
+	}
+}
+
+// Header implements Headerer.
+// requestURL returns the url the client requested, which keeps the prefix
+// Mount strips from r.URL.Path.
+func requestURL(r *http.Request) *url.URL {
+	if u, err := url.ParseRequestURI(r.RequestURI); err == nil {
+		return u
+	}
+	return r.URL
+}
+
+func (p Page[T]) Header() http.Header {
+	header := http.Header{}
+	if p.hasTotal {
+		header.Set("X-Total-Count", strconv.Itoa(p.Total))
+	}
+
+	if p.url == nil {
+		return header
+	}
+
+	links := []string{}
+	if p.Page == 0 {
+		if p.NextCursor != "" {
+			links = append(links, p.link("next", url.Values{"cursor": {p.NextCursor}}))
+		}
+	} else {
+		if p.hasTotal {
+			links = append(links, p.pageLink("first", 1))
+		}
+		if p.Page > 1 {
+			links = append(links, p.pageLink("prev", p.Page-1))
+		}
+		if (p.hasTotal && p.Page < p.TotalPages) || (!p.hasTotal && len(p.Items) >= p.PerPage) {
+			links = append(links, p.pageLink("next", p.Page+1))
+		}
+		if p.hasTotal && p.TotalPages > 0 {
+			links = append(links, p.pageLink("last", p.TotalPages))
+		}
+	}
+
+	if len(links) > 0 {
+		header.Set("Link", strings.Join(links, ", "))
+	}
+
+	return header
+}
+
+func (p Page[T]) pageLink(rel string, page int) string {
+	return p.link(rel, url.Values{"page": {strconv.Itoa(page)}})
+}
+
+func (p Page[T]) link(rel string, values url.Values) string {
+	q := p.url.Query()
+	for k, v := range values {
+		q[k] = v
+	}
+	q.Set("per_page", strconv.Itoa(p.PerPage))
+
+	u := url.URL{Path: p.url.Path, RawPath: p.url.RawPath, RawQuery: q.Encode()}
+	return fmt.Sprintf("<%s>; rel=\"%s\"", u.String(), rel)
+}
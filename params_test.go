@@ -0,0 +1,53 @@
+package japi
+
+import (
+	"context"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+type filesRequest struct {
+	User string   `path:"user"`
+	Path []string `path:"path"`
+}
+
+func TestCatchAllPathParams(t *testing.T) {
+	var got filesRequest
+	files := H(func(_ context.Context, req filesRequest) (*Empty, error) {
+		got = req
+		return &Empty{}, nil
+	})
+
+	child := New(nil)
+	child.Group("/users/:user").Get("/files/*path", files)
+
+	r := New(nil)
+	r.Group("/users/:user").Get("/files/*path", files)
+	r.Mount("/mounted", child)
+
+	tests := []struct {
+		url  string
+		want filesRequest
+	}{
+		{"/users/7/files/a/b", filesRequest{User: "7", Path: []string{"a", "b"}}},
+		{"/users/7/files/a%2Fb/c", filesRequest{User: "7", Path: []string{"a/b", "c"}}},
+		{"/users/7/files/a%2F/%2Fb", filesRequest{User: "7", Path: []string{"a/", "/b"}}},
+		{"/mounted/users/7/files/a%2Fb/c", filesRequest{User: "7", Path: []string{"a/b", "c"}}},
+	}
+
+	h := r.Router()
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			got = filesRequest{}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest("GET", tt.url, nil))
+			if w.Code != 200 {
+				t.Fatalf("status %d: %s", w.Code, w.Body)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
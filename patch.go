@@ -0,0 +1,422 @@
+package japi
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/goccy/go-json"
+
+	"github.com/jarrettv/go-japi/problem"
+)
+
+const (
+	MergePatchEncoding = "application/merge-patch+json"
+	JsonPatchEncoding  = "application/json-patch+json"
+)
+
+var patchFailed = problem.Register("patch-failed", http.StatusConflict, "Patch could not be applied").
+	Describe("The patch operations could not be applied to the record")
+
+// PatchOperation is a single JSON Patch (RFC 6902) operation.
+type PatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// Patch is a request type for partial updates of T. The body can be a
+// JSON Merge Patch (RFC 7396) object or a JSON Patch (RFC 6902) array.
+type Patch[T any] struct {
+	// Value is the decoded merge patch, it is not set for JSON Patch.
+	Value  T
+	merge  any
+	ops    []PatchOperation
+	fields map[string]struct{}
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (p *Patch[T]) UnmarshalJSON(data []byte) error {
+	p.fields = map[string]struct{}{}
+	data = bytes.TrimSpace(data)
+
+	if len(data) > 0 && data[0] == '[' {
+		if err := json.Unmarshal(data, &p.ops); err != nil {
+			return err
+		}
+
+		for _, op := range p.ops {
+			tokens, err := parsePointer(op.Path)
+			if err != nil {
+				return err
+			}
+
+			switch op.Op {
+			case "add", "remove", "replace", "move", "copy", "test":
+			default:
+				return fmt.Errorf("patch: unsupported op %q", op.Op)
+			}
+
+			if op.Op != "test" {
+				p.addField(tokens)
+			}
+			if op.Op == "move" {
+				from, err := parsePointer(op.From)
+				if err != nil {
+					return err
+				}
+				p.addField(from)
+			}
+		}
+
+		return nil
+	}
+
+	if err := unmarshalDoc(data, &p.merge); err != nil {
+		return err
+	}
+
+	m, ok := p.merge.(map[string]any)
+	if !ok {
+		return fmt.Errorf("patch: merge patch must be an object")
+	}
+
+	p.addFields("", m)
+
+	return json.Unmarshal(data, &p.Value)
+}
+
+// IsJSONPatch reports whether the request was a JSON Patch.
+func (p *Patch[T]) IsJSONPatch() bool {
+	return p.ops != nil
+}
+
+// Operations returns the JSON Patch operations.
+func (p *Patch[T]) Operations() []PatchOperation {
+	return p.ops
+}
+
+// Has reports whether the field was present in the patch. Nested fields
+// use the json names separated by dots e.g. "address.city".
+func (p *Patch[T]) Has(field string) bool {
+	_, ok := p.fields[field]
+	return ok
+}
+
+// Fields returns the fields present in the patch.
+func (p *Patch[T]) Fields() []string {
+	fields := make([]string, 0, len(p.fields))
+	for f := range p.fields {
+		fields = append(fields, f)
+	}
+	return fields
+}
+
+// Apply will apply the patch to the target. The target is replaced with the
+// patched json document so fields not encoded to json are reset. A Problem
+// with status 409 is returned when the patch cannot be applied.
+func (p *Patch[T]) Apply(target *T) error {
+	data, err := json.Marshal(target)
+	if err != nil {
+		return err
+	}
+
+	var doc any
+	if err := unmarshalDoc(data, &doc); err != nil {
+		return err
+	}
+
+	if p.ops != nil {
+		for _, op := range p.ops {
+			if doc, err = op.apply(doc); err != nil {
+				return patchProblem(err)
+			}
+		}
+	} else {
+		doc = mergePatch(doc, p.merge)
+	}
+
+	if data, err = json.Marshal(doc); err != nil {
+		return err
+	}
+
+	var v T
+	if err := json.Unmarshal(data, &v); err != nil {
+		return patchProblem(err)
+	}
+
+	*target = v
+
+	return nil
+}
+
+func (p *Patch[T]) addField(tokens []string) {
+	for i := range tokens {
+		p.fields[strings.Join(tokens[:i+1], ".")] = struct{}{}
+	}
+}
+
+func (p *Patch[T]) addFields(prefix string, m map[string]any) {
+	for k, v := range m {
+		p.fields[prefix+k] = struct{}{}
+		if child, ok := v.(map[string]any); ok {
+			p.addFields(prefix+k+".", child)
+		}
+	}
+}
+
+func patchProblem(err error) error {
+	return patchFailed.New(err.Error())
+}
+
+func unmarshalDoc(data []byte, v *any) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
+
+func mergePatch(target, patch any) any {
+	pm, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+
+	tm, ok := target.(map[string]any)
+	if !ok {
+		tm = map[string]any{}
+	}
+
+	for k, v := range pm {
+		if v == nil {
+			delete(tm, k)
+		} else {
+			tm[k] = mergePatch(tm[k], v)
+		}
+	}
+
+	return tm
+}
+
+func parsePointer(path string) ([]string, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	if path[0] != '/' {
+		return nil, fmt.Errorf("patch: invalid path %q", path)
+	}
+
+	tokens := strings.Split(path[1:], "/")
+	for i, t := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(t, "~1", "/"), "~0", "~")
+	}
+
+	return tokens, nil
+}
+
+func (op PatchOperation) value() (any, error) {
+	var v any
+	if len(op.Value) == 0 {
+		return nil, fmt.Errorf("patch: %s %q missing value", op.Op, op.Path)
+	}
+	err := unmarshalDoc(op.Value, &v)
+	return v, err
+}
+
+func (op PatchOperation) apply(doc any) (any, error) {
+	path, err := parsePointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op.Op {
+	case "add":
+		v, err := op.value()
+		if err != nil {
+			return nil, err
+		}
+		return addValue(doc, path, v)
+	case "remove":
+		_, doc, err := removeValue(doc, path)
+		return doc, err
+	case "replace":
+		v, err := op.value()
+		if err != nil {
+			return nil, err
+		}
+		if len(path) == 0 {
+			return v, nil
+		}
+		if _, doc, err = removeValue(doc, path); err != nil {
+			return nil, err
+		}
+		return addValue(doc, path, v)
+	case "move":
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+		v, doc, err := removeValue(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		return addValue(doc, path, v)
+	case "copy":
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+		v, err := getValue(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		if err := unmarshalDoc(data, &v); err != nil {
+			return nil, err
+		}
+		return addValue(doc, path, v)
+	case "test":
+		v, err := op.value()
+		if err != nil {
+			return nil, err
+		}
+		actual, err := getValue(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(actual, v) {
+			return nil, fmt.Errorf("patch: test %q failed", op.Path)
+		}
+		return doc, nil
+	}
+
+	return nil, fmt.Errorf("patch: unsupported op %q", op.Op)
+}
+
+func arrayIndex(token string, n int) (int, error) {
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || i >= n {
+		return 0, fmt.Errorf("patch: invalid index %q", token)
+	}
+	return i, nil
+}
+
+func getValue(doc any, path []string) (any, error) {
+	for _, token := range path {
+		switch c := doc.(type) {
+		case map[string]any:
+			v, ok := c[token]
+			if !ok {
+				return nil, fmt.Errorf("patch: path %q not found", token)
+			}
+			doc = v
+		case []any:
+			i, err := arrayIndex(token, len(c))
+			if err != nil {
+				return nil, err
+			}
+			doc = c[i]
+		default:
+			return nil, fmt.Errorf("patch: path %q not found", token)
+		}
+	}
+	return doc, nil
+}
+
+// update calls fn with the parent container of the path and stores the result.
+func update(doc any, path []string, fn func(parent any, key string) (any, error)) (any, error) {
+	if len(path) == 1 {
+		return fn(doc, path[0])
+	}
+
+	switch c := doc.(type) {
+	case map[string]any:
+		child, ok := c[path[0]]
+		if !ok {
+			return nil, fmt.Errorf("patch: path %q not found", path[0])
+		}
+		v, err := update(child, path[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+		c[path[0]] = v
+		return c, nil
+	case []any:
+		i, err := arrayIndex(path[0], len(c))
+		if err != nil {
+			return nil, err
+		}
+		v, err := update(c[i], path[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+		c[i] = v
+		return c, nil
+	}
+
+	return nil, fmt.Errorf("patch: path %q not found", path[0])
+}
+
+func addValue(doc any, path []string, v any) (any, error) {
+	if len(path) == 0 {
+		return v, nil
+	}
+
+	return update(doc, path, func(parent any, key string) (any, error) {
+		switch c := parent.(type) {
+		case map[string]any:
+			c[key] = v
+			return c, nil
+		case []any:
+			if key == "-" {
+				return append(c, v), nil
+			}
+			i, err := arrayIndex(key, len(c)+1)
+			if err != nil {
+				return nil, err
+			}
+			c = append(c, nil)
+			copy(c[i+1:], c[i:])
+			c[i] = v
+			return c, nil
+		}
+		return nil, fmt.Errorf("patch: path %q not found", key)
+	})
+}
+
+func removeValue(doc any, path []string) (any, any, error) {
+	if len(path) == 0 {
+		return nil, nil, fmt.Errorf("patch: cannot remove the document")
+	}
+
+	var removed any
+	doc, err := update(doc, path, func(parent any, key string) (any, error) {
+		switch c := parent.(type) {
+		case map[string]any:
+			v, ok := c[key]
+			if !ok {
+				return nil, fmt.Errorf("patch: path %q not found", key)
+			}
+			removed = v
+			delete(c, key)
+			return c, nil
+		case []any:
+			i, err := arrayIndex(key, len(c))
+			if err != nil {
+				return nil, err
+			}
+			removed = c[i]
+			return append(c[:i], c[i+1:]...), nil
+		}
+		return nil, fmt.Errorf("patch: path %q not found", key)
+	})
+
+	return removed, doc, err
+}
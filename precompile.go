@@ -0,0 +1,53 @@
+package japi
+
+import (
+	"reflect"
+	"sync"
+
+	"github.com/goccy/go-json"
+
+	"github.com/jarrettv/go-japi/problem"
+)
+
+var responseTypes = struct {
+	sync.Mutex
+	types map[reflect.Type]bool
+}{types: map[reflect.Type]bool{reflect.TypeOf(problem.Problem{}): true}}
+
+// addResponseType records the response type of a handler for PrecompileEncoders.
+func addResponseType(t reflect.Type) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Interface || jsonCodecFor(t) != nil {
+		return
+	}
+
+	responseTypes.Lock()
+	defer responseTypes.Unlock()
+	responseTypes.types[t] = true
+}
+
+// PrecompileEncoders compiles the json encoders of the response types of the
+// handlers created so far and of the problems. The encoders are otherwise
+// compiled on the first response of each type which adds to its latency. Call
+// it after the routes are registered and before serving.
+func PrecompileEncoders() {
+	responseTypes.Lock()
+	types := make([]reflect.Type, 0, len(responseTypes.types))
+	for t := range responseTypes.types {
+		types = append(types, t)
+	}
+	responseTypes.Unlock()
+
+	for _, t := range types {
+		precompile(t)
+	}
+}
+
+// precompile encodes a zero value of the type which compiles and caches its
+// encoder. Errors of the zero value e.g. from MarshalJSON are ignored.
+func precompile(t reflect.Type) {
+	defer func() { _ = recover() }()
+	_, _ = json.Marshal(reflect.New(t).Interface())
+}
@@ -0,0 +1,85 @@
+package problem
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Definition is a problem type declared in the catalog.
+type Definition struct {
+	Code        string `json:"type"`
+	Status      int    `json:"status"`
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+}
+
+var (
+	catalogMu sync.RWMutex
+	catalog   = map[string]*Definition{}
+)
+
+// Register declares the problem type in the catalog and returns the definition
+// to create the problems with. It panics when the code is already registered.
+func Register(code string, status int, title string) *Definition {
+	catalogMu.Lock()
+	defer catalogMu.Unlock()
+
+	if _, ok := catalog[code]; ok {
+		panic(fmt.Sprintf("problem: type %q is already registered", code))
+	}
+	d := &Definition{Code: code, Status: status, Title: title}
+	catalog[code] = d
+	return d
+}
+
+// Describe sets the description of the problem type in the catalog.
+func (d *Definition) Describe(description string) *Definition {
+	catalogMu.Lock()
+	defer catalogMu.Unlock()
+
+	d.Description = description
+	return d
+}
+
+// New creates a problem of the type with the detail.
+func (d *Definition) New(detail string) *Problem {
+	return New(d.Status, d.Code, d.Title, detail, "", nil)
+}
+
+// Newf creates a problem of the type with the formatted detail.
+func (d *Definition) Newf(format string, args ...any) *Problem {
+	return d.New(fmt.Sprintf(format, args...))
+}
+
+// Is reports whether the error is a problem of the type.
+func (d *Definition) Is(err error) bool {
+	var p *Problem
+	return errors.As(err, &p) && Is(p, d.Code)
+}
+
+// Catalog returns the registered problem types sorted by code.
+func Catalog() []Definition {
+	catalogMu.RLock()
+	defer catalogMu.RUnlock()
+
+	defs := make([]Definition, 0, len(catalog))
+	for _, d := range catalog {
+		defs = append(defs, *d)
+	}
+	sort.Slice(defs, func(i, j int) bool { return defs[i].Code < defs[j].Code })
+	return defs
+}
+
+// Lookup returns the registered problem type of the code.
+func Lookup(code string) (Definition, bool) {
+	catalogMu.RLock()
+	defer catalogMu.RUnlock()
+
+	d, ok := catalog[code]
+	if !ok {
+		return Definition{}, false
+	}
+	return *d, true
+}
@@ -0,0 +1,55 @@
+package problem
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// Parse will decode the problem details of the response. It returns nil for
+// successful responses and a status problem when the error response is not
+// problem details json. The response body is read but not closed.
+func Parse(resp *http.Response) (*Problem, error) {
+	if resp.StatusCode < http.StatusBadRequest {
+		return nil, nil
+	}
+
+	mt, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mt != ContentType {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return Status(resp.StatusCode), nil
+	}
+
+	p := &Problem{}
+	if err := json.NewDecoder(resp.Body).Decode(p); err != nil {
+		return nil, err
+	}
+	if p.Status == 0 {
+		p.Status = resp.StatusCode
+	}
+	for _, k := range problemHeaders {
+		if v := resp.Header.Values(k); len(v) > 0 {
+			if p.Headers == nil {
+				p.Headers = http.Header{}
+			}
+			p.Headers[k] = v
+		}
+	}
+	return p, nil
+}
+
+// Is will report whether the error is a problem with the type code. Types
+// formatted as a URI match when the last path segment or fragment is the code.
+func Is(err error, code string) bool {
+	var p *Problem
+	if !errors.As(err, &p) {
+		return false
+	}
+
+	return p.Type == code ||
+		strings.HasSuffix(p.Type, "/"+code) ||
+		strings.HasSuffix(p.Type, "#"+code)
+}
@@ -0,0 +1,153 @@
+package problem
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// XMLContentType is the media type of problem details xml.
+const XMLContentType = "application/problem+xml"
+
+// Encoder writes a problem in its media type.
+type Encoder interface {
+	ContentType() string
+	Encode(w io.Writer, p *Problem) error
+}
+
+type jsonEncoder struct{}
+
+func (jsonEncoder) ContentType() string { return ContentType }
+
+func (jsonEncoder) Encode(w io.Writer, p *Problem) error {
+	return json.NewEncoder(w).Encode(p)
+}
+
+type xmlEncoder struct{}
+
+func (xmlEncoder) ContentType() string { return XMLContentType }
+
+// Encode writes the problem with the urn:ietf:rfc:7807 namespace of the RFC 7807
+// xml format.
+func (xmlEncoder) Encode(w io.Writer, p *Problem) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	if err := enc.Encode(newXMLProblem(p)); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+type xmlProblem struct {
+	XMLName  xml.Name   `xml:"urn:ietf:rfc:7807 problem"`
+	Type     string     `xml:"type"`
+	Title    string     `xml:"title"`
+	Status   int        `xml:"status,omitempty"`
+	Detail   string     `xml:"detail,omitempty"`
+	Instance string     `xml:"instance,omitempty"`
+	Params   *xmlParams `xml:"params"`
+	Errors   *xmlErrors `xml:"errors"`
+}
+
+type xmlParams struct {
+	Params []xmlParam `xml:"param"`
+}
+
+type xmlParam struct {
+	Name    string `xml:"name,attr"`
+	Message string `xml:",chardata"`
+}
+
+type xmlErrors struct {
+	Errors []xmlError `xml:"error"`
+}
+
+type xmlError struct {
+	Name     string   `xml:"name,attr"`
+	Messages []string `xml:"i"`
+}
+
+// newXMLProblem sorts the params and errors by name as xml has no maps.
+func newXMLProblem(p *Problem) xmlProblem {
+	xp := xmlProblem{Type: p.Type, Title: p.Title, Status: p.Status, Detail: p.Detail, Instance: p.Instance}
+	if len(p.Params) > 0 {
+		xp.Params = &xmlParams{}
+		for name, msg := range p.Params {
+			xp.Params.Params = append(xp.Params.Params, xmlParam{Name: name, Message: msg})
+		}
+		sort.Slice(xp.Params.Params, func(i, j int) bool { return xp.Params.Params[i].Name < xp.Params.Params[j].Name })
+	}
+	if len(p.Errors) > 0 {
+		xp.Errors = &xmlErrors{}
+		for name, msgs := range p.Errors {
+			xp.Errors.Errors = append(xp.Errors.Errors, xmlError{Name: name, Messages: msgs})
+		}
+		sort.Slice(xp.Errors.Errors, func(i, j int) bool { return xp.Errors.Errors[i].Name < xp.Errors.Errors[j].Name })
+	}
+	return xp
+}
+
+var (
+	// JSON encodes the problems as application/problem+json.
+	JSON Encoder = jsonEncoder{}
+	// XML encodes the problems as application/problem+xml.
+	XML Encoder = xmlEncoder{}
+)
+
+// encoders are matched to the Accept header with json as the default.
+var encoders = map[string]Encoder{}
+
+func init() {
+	RegisterEncoder(JSON, "application/json")
+	RegisterEncoder(XML, "application/xml", "text/xml")
+}
+
+// RegisterEncoder registers the encoder for its content type and the aliases
+// e.g. application/xml for problem xml. Encoders must be registered before the
+// problems are served.
+func RegisterEncoder(e Encoder, aliases ...string) {
+	encoders[e.ContentType()] = e
+	for _, alias := range aliases {
+		encoders[alias] = e
+	}
+}
+
+// negotiate returns the encoder of the media type the Accept header prefers,
+// json when none is acceptable.
+func negotiate(r *http.Request) Encoder {
+	if r == nil {
+		return JSON
+	}
+
+	best, bestQ := JSON, 0.0
+	for _, accept := range r.Header.Values("Accept") {
+		for _, part := range strings.Split(accept, ",") {
+			mt, params, err := mime.ParseMediaType(part)
+			if err != nil {
+				continue
+			}
+			e, ok := encoders[mt]
+			if !ok {
+				continue
+			}
+			q := 1.0
+			if v, ok := params["q"]; ok {
+				if q, err = strconv.ParseFloat(v, 64); err != nil {
+					continue
+				}
+			}
+			if q > bestQ {
+				best, bestQ = e, q
+			}
+		}
+	}
+	return best
+}
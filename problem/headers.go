@@ -0,0 +1,51 @@
+package problem
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// problemHeaders are the standard headers kept by Parse.
+var problemHeaders = []string{"Retry-After", "Www-Authenticate", "Allow"}
+
+// WithHeader will set the response header sent with the problem.
+func (pd *Problem) WithHeader(key, value string) *Problem {
+	if pd.Headers == nil {
+		pd.Headers = http.Header{}
+	}
+	pd.Headers.Set(key, value)
+	return pd
+}
+
+// WithRetryAfter will set the Retry-After header in seconds rounded up.
+func (pd *Problem) WithRetryAfter(d time.Duration) *Problem {
+	return pd.WithHeader("Retry-After", strconv.Itoa(int((d+time.Second-1)/time.Second)))
+}
+
+// WithAuthenticate will set the WWW-Authenticate challenge e.g. Bearer realm="api".
+func (pd *Problem) WithAuthenticate(challenge string) *Problem {
+	return pd.WithHeader("WWW-Authenticate", challenge)
+}
+
+// WithAllow will set the Allow header with the allowed methods.
+func (pd *Problem) WithAllow(methods ...string) *Problem {
+	return pd.WithHeader("Allow", strings.Join(methods, ", "))
+}
+
+// RetryAfter returns the Retry-After of the problem and whether it has one.
+// The header can be in seconds or an HTTP date.
+func (pd *Problem) RetryAfter() (time.Duration, bool) {
+	v := pd.Headers.Get("Retry-After")
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return time.Until(t), true
+	}
+	return 0, false
+}
@@ -34,6 +34,27 @@ type Problem struct {
 	// Params are request input field level errors. They communicate
 	// back to client hints as to the exact problem.
 	Params map[string]string `json:"params,omitempty"`
+	// Errors are request input field level errors with all the
+	// messages for each field.
+	Errors Errors `json:"errors,omitempty"`
+	// Headers are the response headers sent with the problem e.g.
+	// Retry-After, WWW-Authenticate or Allow.
+	Headers http.Header `json:"-"`
+
+	err error
+}
+
+// Errors are field level errors keyed by the field name.
+type Errors map[string][]string
+
+// Add will add the message for the field.
+func (e Errors) Add(field, message string) {
+	e[field] = append(e[field], message)
+}
+
+// Unwrap returns the error of unexpected problems.
+func (pd *Problem) Unwrap() error {
+	return pd.err
 }
 
 // Error implements the error interface
@@ -79,45 +100,79 @@ func Status(statusCode int) *Problem {
 	return New(statusCode, "", "", "", "", nil)
 }
 
+// The built in problem types of the catalog.
+var (
+	unexpected   = Register("unexpected", http.StatusInternalServerError, "Unexpected problem").Describe("The server failed to handle the request")
+	notFound     = Register("not-found", http.StatusNotFound, "Record not found").Describe("The requested record does not exist")
+	notPermitted = Register("not-permitted", http.StatusForbidden, "User not permitted").Describe("The user does not have permission for the request")
+	badRequest   = Register("bad-request", http.StatusBadRequest, "Bad or malformed request").Describe("The request could not be read")
+	validation   = Register("validation", http.StatusBadRequest, "Validation failed").Describe("The request has invalid fields listed in the errors")
+	ruleViolated = Register("rule-violated", http.StatusBadRequest, "Rule violated").Describe("The request violates the business rule in the detail")
+	notCurrent   = Register("not-current", http.StatusConflict, "Record not current").Describe("The record was changed by another request")
+	timeout      = Register("timeout", http.StatusGatewayTimeout, "Request timed out").Describe("The request took too long to process")
+	unavailable  = Register("unavailable", http.StatusServiceUnavailable, "Service unavailable").Describe("The service is too busy to handle the request")
+)
+
 // Unexpected will create a new problem for unexpected errors.
 func Unexpected(err error) *Problem {
-	return New(http.StatusInternalServerError, "unexpected", "Unexpected problem",
-		err.Error(), "", nil)
+	p := unexpected.New(err.Error())
+	p.err = err
+	return p
 }
 
 // NotFound will create a new problem for when the record is not found.
 func NotFound() *Problem {
-	return New(http.StatusNotFound, "not-found", "Record not found",
-		"", "", nil)
+	return notFound.New("")
 }
 
 // NotPermitted will create a new problem for when the user is forbidden access.
 func NotPermitted(username string) *Problem {
-	detail := fmt.Sprintf("%s does not have proper permissions", username)
-	return New(http.StatusForbidden, "not-permitted", "User not permitted",
-		detail, "", nil)
+	return notPermitted.Newf("%s does not have proper permissions", username)
 }
 
 // BadRequest will create a new problem for reqeust marshalling errors.
 func BadRequest(err error) *Problem {
-	return New(http.StatusBadRequest, "bad-request", "Bad or malformed request",
-		"Fix the error and try again", "", nil)
+	return badRequest.New("Fix the error and try again")
 }
 
 // Validation will create a new problem for when request has field validation errors.
 func Validation(params map[string]string) *Problem {
-	return New(http.StatusBadRequest, "validation", "Validation failed",
-		"Fix the errors and try again", "", params)
+	p := validation.New("Fix the errors and try again")
+	p.Params = params
+	return p
+}
+
+// ValidationErrors will create a new problem for when request has field validation
+// errors. The first message for each field is also set in the params.
+func ValidationErrors(errs Errors) *Problem {
+	params := make(map[string]string, len(errs))
+	for field, msgs := range errs {
+		if len(msgs) > 0 {
+			params[field] = msgs[0]
+		}
+	}
+
+	p := Validation(params)
+	p.Errors = errs
+	return p
 }
 
 // RuleViolated will create a new problem for when a business rule is violated.
 func RuleViolated(rule string) *Problem {
-	return New(http.StatusBadRequest, "rule-violated", "Rule violated",
-		rule, "", nil)
+	return ruleViolated.New(rule)
 }
 
 // NotCurrent will create a new problem for optimistic concurrency errors.
 func NotCurrent() *Problem {
-	return New(http.StatusConflict, "not-current", "Record not current",
-		"Reload and try your changes again", "", nil)
+	return notCurrent.New("Reload and try your changes again")
+}
+
+// Timeout will create a new problem for when the request deadline is exceeded.
+func Timeout() *Problem {
+	return timeout.New("The request took too long to process")
+}
+
+// Unavailable will create a new problem for when the service is too busy to handle the request.
+func Unavailable() *Problem {
+	return unavailable.New("The service is busy, retry the request later")
 }
@@ -2,12 +2,14 @@ package problem
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"net/http"
 	"strings"
 )
 
+// ContentType is the media type of problem details json.
+const ContentType = "application/problem+json"
+
 type ProblemConfig struct {
 	// the URI to be string formatted with default type codes
 	ProblemTypeUrlFormat string
@@ -25,12 +27,40 @@ func (cfg ProblemConfig) Enrich(ctx context.Context, p *Problem) {
 	}
 }
 
+// Serve will output the Problem Details to the response writer in the media
+// type the request Accept header prefers, json by default or with a nil request.
+func (pd *Problem) Serve(w http.ResponseWriter, r *http.Request) error {
+	if r != nil && !varies(w.Header(), "Accept") {
+		w.Header().Add("Vary", "Accept")
+	}
+	return pd.ServeEncoded(w, negotiate(r))
+}
+
+func varies(h http.Header, name string) bool {
+	for _, v := range h.Values("Vary") {
+		for _, t := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ServeEncoded will output the Problem Details to the response writer with the
+// encoder.
+func (pd *Problem) ServeEncoded(w http.ResponseWriter, e Encoder) error {
+	for k, v := range pd.Headers {
+		w.Header()[k] = v
+	}
+	w.Header().Set("Content-Type", e.ContentType())
+	w.WriteHeader(pd.Status)
+	return e.Encode(w, pd)
+}
+
 // ServeJSON will output Problem Details json to the response writer.
+//
+// Deprecated: Use Serve to negotiate the media type or ServeEncoded.
 func (pd *Problem) ServeJSON(w http.ResponseWriter) error {
-	w.Header().Set("Content-Type", "application/problem+json")
-	w.WriteHeader(pd.Status)
-	if err := json.NewEncoder(w).Encode(pd); err != nil {
-		return err
-	}
-	return nil
+	return pd.ServeEncoded(w, JSON)
 }
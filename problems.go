@@ -0,0 +1,44 @@
+package japi
+
+import (
+	"net/http"
+
+	"github.com/jarrettv/go-japi/problem"
+)
+
+// Problems serves the problems of a handler of another package with the config
+// of the API it is registered with, so they are enriched, logged and reported
+// like the problems of the japi handlers.
+type Problems struct {
+	config *configRef
+}
+
+// ProblemsSetter is implemented by the handlers of other packages e.g. ws to
+// be given the Problems of the API they are registered with.
+type ProblemsSetter interface {
+	SetProblems(p Problems)
+}
+
+// Prepare returns the problem of the error for the request after it is
+// enriched, logged and sanitized. Errors that are not problems are unexpected.
+func (p Problems) Prepare(r *http.Request, err error) *problem.Problem {
+	prob, ok := asProblem(err)
+	if !ok {
+		prob = problem.Unexpected(err)
+	}
+
+	p.load().prepareProblem(withRequest(r), prob)
+	return prob
+}
+
+// Serve serves the problem of the error for the request.
+func (p Problems) Serve(w http.ResponseWriter, r *http.Request, err error) {
+	p.Prepare(r, err).Serve(w, r)
+}
+
+func (p Problems) load() *Config {
+	if p.config == nil {
+		return GetDefaultConfig()
+	}
+	return p.config.Load()
+}
@@ -0,0 +1,112 @@
+// Package protobuf registers the protobuf codecs with japi. Import it for its
+// side effects to share your message definitions between gRPC and japi.
+//
+//	import _ "github.com/jarrettv/go-japi/protobuf"
+//
+// Requests with the application/x-protobuf content type are decoded with the
+// protobuf binary encoding and message responses are encoded with it when the
+// request prefers it. Messages are otherwise decoded and encoded with
+// protojson and other responses are json.
+package protobuf
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/jarrettv/go-japi"
+)
+
+// ContentType is the media type of the protobuf binary encoding.
+const ContentType = "application/x-protobuf"
+
+var (
+	// MarshalOptions are the protojson options used to encode json responses.
+	MarshalOptions = protojson.MarshalOptions{}
+	// UnmarshalOptions are the protojson options used to decode json requests.
+	UnmarshalOptions = protojson.UnmarshalOptions{DiscardUnknown: true}
+)
+
+var messageType = reflect.TypeOf((*proto.Message)(nil)).Elem()
+
+func init() {
+	japi.RegisterJSONCodec(japi.JSONCodec{
+		Match: isMessage,
+		Marshal: func(v any) ([]byte, error) {
+			m, err := message(v)
+			if err != nil {
+				return nil, err
+			}
+			return MarshalOptions.Marshal(m)
+		},
+		Unmarshal: func(data []byte, v any) error {
+			m, err := target(v)
+			if err != nil {
+				return err
+			}
+			return UnmarshalOptions.Unmarshal(data, m)
+		},
+	})
+
+	japi.RegisterDecoder(ContentType, func(data []byte, v any) error {
+		m, err := target(v)
+		if err != nil {
+			return err
+		}
+		return proto.Unmarshal(data, m)
+	})
+
+	japi.RegisterTypedEncoder(ContentType, func(w io.Writer, v any) error {
+		m, err := message(v)
+		if err != nil {
+			return err
+		}
+		data, err := proto.Marshal(m)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	}, isMessage)
+}
+
+// isMessage reports whether the type or its pointer is a message.
+func isMessage(t reflect.Type) bool {
+	return t.Implements(messageType) || reflect.PointerTo(t).Implements(messageType)
+}
+
+// message returns the message of the response value.
+func message(v any) (proto.Message, error) {
+	if m, ok := v.(proto.Message); ok {
+		return m, nil
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.IsValid() && reflect.PointerTo(rv.Type()).Implements(messageType) {
+		ptr := reflect.New(rv.Type())
+		ptr.Elem().Set(rv)
+		return ptr.Interface().(proto.Message), nil
+	}
+	return nil, fmt.Errorf("protobuf: %T is not a proto.Message", v)
+}
+
+// target returns the message to decode into. The message is allocated when v
+// is a pointer to a nil message pointer.
+func target(v any) (proto.Message, error) {
+	if m, ok := v.(proto.Message); ok {
+		return m, nil
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Pointer && !rv.IsNil() && rv.Elem().Kind() == reflect.Pointer &&
+		rv.Elem().Type().Implements(messageType) {
+		if rv.Elem().IsNil() {
+			rv.Elem().Set(reflect.New(rv.Elem().Type().Elem()))
+		}
+		return rv.Elem().Interface().(proto.Message), nil
+	}
+	return nil, fmt.Errorf("protobuf: %T is not a proto.Message", v)
+}
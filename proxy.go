@@ -0,0 +1,184 @@
+package japi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/jarrettv/go-japi/problem"
+)
+
+var badGateway = problem.Register("bad-gateway", http.StatusBadGateway, "Bad Gateway").
+	Describe("The upstream service could not be reached")
+
+// ProxyOption configures the proxy handler.
+type ProxyOption func(*proxyHandler)
+
+// ProxyStripPrefix removes the prefix from the request path before it is
+// joined to the target path.
+func ProxyStripPrefix(prefix string) ProxyOption {
+	return func(h *proxyHandler) {
+		h.rewrite = append(h.rewrite, func(path string) string {
+			if p := strings.TrimPrefix(path, prefix); p != path {
+				return "/" + strings.TrimPrefix(p, "/")
+			}
+			return path
+		})
+	}
+}
+
+// ProxyRewrite rewrites the request path before it is joined to the target
+// path.
+func ProxyRewrite(rewrite func(path string) string) ProxyOption {
+	return func(h *proxyHandler) {
+		h.rewrite = append(h.rewrite, rewrite)
+	}
+}
+
+// ProxyHeader sets the header of the upstream request from the request context
+// e.g. the user id set by an auth middleware. Empty values are not set.
+func ProxyHeader(name string, value func(ctx context.Context) string) ProxyOption {
+	return func(h *proxyHandler) {
+		h.headers = append(h.headers, proxyHeader{name: name, value: value})
+	}
+}
+
+// ProxyTransport sends the upstream requests with the transport.
+func ProxyTransport(t http.RoundTripper) ProxyOption {
+	return func(h *proxyHandler) {
+		h.proxy.Transport = t
+	}
+}
+
+type proxyHeader struct {
+	name  string
+	value func(ctx context.Context) string
+}
+
+type proxyHandler struct {
+	config  *configRef
+	target  *url.URL
+	proxy   *httputil.ReverseProxy
+	rewrite []func(path string) string
+	headers []proxyHeader
+}
+
+// upstreamError is the error response of the upstream.
+type upstreamError struct {
+	res *http.Response
+}
+
+func (e *upstreamError) Error() string {
+	return fmt.Sprintf("japi: upstream responded %d", e.res.StatusCode)
+}
+
+// Proxy forwards the requests to the upstream target URL. Failures to reach the
+// upstream respond with a 502 problem and timeouts with a 504 problem. The 4xx
+// and 5xx upstream responses that are not problems are converted to problems
+// with the same status. It panics when the target is not a valid URL.
+func Proxy(target string, opts ...ProxyOption) Handler {
+	u, err := url.Parse(target)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		panic(fmt.Sprintf("japi: invalid proxy target %q", target))
+	}
+
+	h := &proxyHandler{target: u, proxy: &httputil.ReverseProxy{}}
+	h.proxy.Rewrite = h.rewriteRequest
+	h.proxy.ModifyResponse = checkUpstream
+	h.proxy.ErrorHandler = h.serveError
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+func (h *proxyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.handle(w, r, nil)
+}
+
+func (h *proxyHandler) handle(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	h.proxy.ServeHTTP(w, r)
+}
+
+func (h *proxyHandler) rewriteRequest(pr *httputil.ProxyRequest) {
+	path := pr.In.URL.Path
+	for _, rewrite := range h.rewrite {
+		path = rewrite(path)
+	}
+	pr.Out.URL.Path, pr.Out.URL.RawPath = path, ""
+
+	pr.SetURL(h.target)
+	pr.SetXForwarded()
+
+	ctx := pr.In.Context()
+	if h.config != nil {
+		if t := h.config.Load().RequestTimeouts; t.Max > 0 {
+			if deadline, ok := ctx.Deadline(); ok {
+				// the upstream gets the rest of the budget
+				pr.Out.Header.Del("Grpc-Timeout")
+				pr.Out.Header.Set(t.header(), max(time.Until(deadline), time.Millisecond).Round(time.Millisecond).String())
+			}
+		}
+	}
+	for _, header := range h.headers {
+		if v := header.value(ctx); v != "" {
+			pr.Out.Header.Set(header.name, v)
+		}
+	}
+}
+
+// checkUpstream passes the error responses that are not problems to the error
+// handler.
+func checkUpstream(res *http.Response) error {
+	if res.StatusCode < http.StatusBadRequest {
+		return nil
+	}
+	if mt, _, _ := mime.ParseMediaType(res.Header.Get("Content-Type")); mt == problem.ContentType || mt == problem.XMLContentType {
+		return nil
+	}
+	return &upstreamError{res: res}
+}
+
+func (h *proxyHandler) serveError(w http.ResponseWriter, r *http.Request, err error) {
+	var ue *upstreamError
+	var ne net.Error
+	var p *problem.Problem
+
+	switch {
+	case errors.As(err, &ue):
+		_, _ = io.Copy(io.Discard, io.LimitReader(ue.res.Body, 1<<16))
+		_ = ue.res.Body.Close()
+		p = problem.Status(ue.res.StatusCode)
+		if v := ue.res.Header.Get("Retry-After"); v != "" {
+			p.WithHeader("Retry-After", v)
+		}
+	case isShutdown(r.Context()):
+		p = shutdownProblem()
+	case errors.Is(err, context.Canceled) && r.Context().Err() != nil:
+		return // the client is gone
+	case errors.Is(err, context.DeadlineExceeded) || errors.As(err, &ne) && ne.Timeout():
+		p = problem.Timeout()
+	default:
+		p = badGateway.New("")
+	}
+
+	c := GetDefaultConfig()
+	if h.config != nil {
+		c = h.config.Load()
+	}
+	c.serveProblem(w, r, p)
+}
+
+func (h *proxyHandler) setConfig(c *configRef) {
+	h.config = c
+}
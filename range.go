@@ -0,0 +1,112 @@
+package japi
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/goccy/go-json"
+
+	"github.com/jarrettv/go-japi/problem"
+)
+
+var rangeNotSatisfiable = problem.Register("range-not-satisfiable", http.StatusRequestedRangeNotSatisfiable, "Range not satisfiable").
+	Describe("The requested range starts after the last item")
+
+// RangeRequest can be embedded in your request to decode the Range: items=0-49
+// header of GET requests. Without a valid items range it is the first
+// DefaultPerPage items and ranges are limited to MaxPerPage items.
+type RangeRequest struct {
+	Offset int `json:"-"`
+	Limit  int `json:"-"`
+	ranged bool
+}
+
+// AfterDecode implements AfterDecoder to parse the range and apply the limits.
+func (rr *RangeRequest) AfterDecode(r *http.Request) error {
+	rr.Offset, rr.Limit, rr.ranged = 0, DefaultPerPage, false
+	if r.Method != http.MethodGet {
+		return nil
+	}
+
+	// invalid ranges are ignored as the header is optional
+	spec, ok := strings.CutPrefix(strings.TrimSpace(r.Header.Get("Range")), "items=")
+	if !ok || strings.Contains(spec, ",") {
+		return nil
+	}
+	first, last, _ := strings.Cut(strings.TrimSpace(spec), "-")
+	offset, err := strconv.Atoi(first)
+	if err != nil || offset < 0 {
+		return nil
+	}
+	limit := DefaultPerPage
+	if last != "" {
+		end, err := strconv.Atoi(last)
+		if err != nil || end < offset {
+			return nil
+		}
+		limit = end - offset + 1
+	}
+
+	rr.Offset, rr.Limit, rr.ranged = offset, min(limit, MaxPerPage), true
+	return nil
+}
+
+// Ranged reports whether the request has a Range header.
+func (rr RangeRequest) Ranged() bool {
+	return rr.ranged
+}
+
+// RangeResponse is a response with the items of a range encoded as a json
+// array. It adds the Content-Range: items 0-49/200 header and responds with
+// 206 Partial Content unless the items are the whole collection.
+type RangeResponse[T any] struct {
+	Items  []T
+	Offset int
+	Total  int
+}
+
+// NewRangeResponse creates the response of the range of items. Use a negative
+// total when the total is not known. It returns a 416 problem when the range
+// starts after the last item.
+func NewRangeResponse[T any](req RangeRequest, items []T, total int) (*RangeResponse[T], error) {
+	if total >= 0 && req.Offset > 0 && req.Offset >= total {
+		return nil, rangeNotSatisfiable.New(fmt.Sprintf("The range starts after the last of %d items", total)).
+			WithHeader("Content-Range", fmt.Sprintf("items */%d", total))
+	}
+	return &RangeResponse[T]{Items: items, Offset: req.Offset, Total: total}, nil
+}
+
+// MarshalJSON implements json.Marshaler.
+func (rr RangeResponse[T]) MarshalJSON() ([]byte, error) {
+	if rr.Items == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(rr.Items)
+}
+
+// Header implements Headerer.
+func (rr RangeResponse[T]) Header() http.Header {
+	total := "*"
+	if rr.Total >= 0 {
+		total = strconv.Itoa(rr.Total)
+	}
+
+	header := http.Header{}
+	header.Set("Accept-Ranges", "items")
+	if len(rr.Items) == 0 {
+		header.Set("Content-Range", "items */"+total)
+	} else {
+		header.Set("Content-Range", fmt.Sprintf("items %d-%d/%s", rr.Offset, rr.Offset+len(rr.Items)-1, total))
+	}
+	return header
+}
+
+// StatusCode implements StatusCoder.
+func (rr RangeResponse[T]) StatusCode() int {
+	if rr.Offset == 0 && rr.Total >= 0 && len(rr.Items) >= rr.Total {
+		return http.StatusOK
+	}
+	return http.StatusPartialContent
+}
@@ -0,0 +1,32 @@
+package japi
+
+import (
+	"io"
+	"net/http"
+	"reflect"
+)
+
+var readCloserType = reflect.TypeOf((*io.ReadCloser)(nil)).Elem()
+
+// newRawBody returns the func to set the untouched request body for []byte
+// types like json.RawMessage and io.Reader interfaces, nil for other types.
+func newRawBody(t reflect.Type) func(r *http.Request, v any) error {
+	switch {
+	case t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8:
+		return func(r *http.Request, v any) error {
+			data, err := io.ReadAll(r.Body)
+			if err != nil {
+				return err
+			}
+			reflect.ValueOf(v).Elem().SetBytes(data)
+			return nil
+		}
+	case t.Kind() == reflect.Interface && t.NumMethod() > 0 && readCloserType.Implements(t):
+		return func(r *http.Request, v any) error {
+			reflect.ValueOf(v).Elem().Set(reflect.ValueOf(r.Body))
+			return nil
+		}
+	}
+
+	return nil
+}
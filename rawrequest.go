@@ -0,0 +1,33 @@
+package japi
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+)
+
+// RawRequest gives typed handlers the raw request. Embed it in your request
+// struct and it is set after the request is decoded.
+type RawRequest struct {
+	Request *http.Request `json:"-"`
+}
+
+func (rr *RawRequest) setRawRequest(r *http.Request) {
+	rr.Request = r
+}
+
+type rawRequester interface {
+	setRawRequest(r *http.Request)
+}
+
+var rawRequesterType = reflect.TypeOf((*rawRequester)(nil)).Elem()
+
+type writerKey struct{}
+
+// ResponseWriterFrom returns the response writer of the typed handler for
+// cases like trailers and hijacking. The handler response is not encoded when
+// the handler writes the response itself.
+func ResponseWriterFrom(ctx context.Context) (http.ResponseWriter, bool) {
+	w, ok := ctx.Value(writerKey{}).(http.ResponseWriter)
+	return w, ok
+}
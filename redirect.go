@@ -0,0 +1,45 @@
+package japi
+
+import (
+	"net/http"
+)
+
+// Redirecter allows you to respond with a redirect instead of json.
+type Redirecter interface {
+	RedirectTo() (url string, status int)
+}
+
+// Redirect is a response that redirects to the URL. Status defaults to 302.
+type Redirect struct {
+	URL    string
+	Status int
+}
+
+// RedirectTo implements Redirecter.
+func (rd Redirect) RedirectTo() (string, int) {
+	return rd.URL, rd.Status
+}
+
+// serveRedirect will serve redirect responses, returning false for other
+// responses.
+func serveRedirect(w http.ResponseWriter, r *http.Request, res any) bool {
+	rd, ok := res.(Redirecter)
+	if !ok {
+		return false
+	}
+
+	url, status := rd.RedirectTo()
+	if status == 0 {
+		status = http.StatusFound
+	}
+
+	if h, ok := res.(Headerer); ok {
+		headers := w.Header()
+		for k, v := range h.Header() {
+			headers[k] = v
+		}
+	}
+
+	http.Redirect(w, r, url, status)
+	return true
+}
@@ -0,0 +1,40 @@
+package japi
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/jarrettv/go-japi/problem"
+)
+
+// ErrorReporter reports the errors of 5xx problems to error tracking. The err
+// is the original error of unexpected problems otherwise the problem.
+type ErrorReporter interface {
+	Report(ctx context.Context, err error, p *problem.Problem)
+}
+
+type requestKey struct{}
+
+// withRequest returns the request context with the request for the reporter.
+func withRequest(r *http.Request) context.Context {
+	return context.WithValue(r.Context(), requestKey{}, r)
+}
+
+// RequestFrom returns the request of the problem in the ErrorReporter or nil
+// when the problem is not for a request e.g. a failed job.
+func RequestFrom(ctx context.Context) *http.Request {
+	r, _ := ctx.Value(requestKey{}).(*http.Request)
+	return r
+}
+
+func (c *Config) report(ctx context.Context, p *problem.Problem) {
+	if c.ErrorReporter == nil || p.Status < http.StatusInternalServerError {
+		return
+	}
+
+	err := p.Unwrap()
+	if err == nil {
+		err = p
+	}
+	c.ErrorReporter.Report(ctx, err, p)
+}
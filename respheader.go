@@ -0,0 +1,144 @@
+package japi
+
+import (
+	"bytes"
+	stdjson "encoding/json"
+	"net/http"
+	"reflect"
+
+	"github.com/goccy/go-json"
+
+	"github.com/jarrettv/go-japi/decoder"
+)
+
+// headerEncoder sets the header tagged fields of the response as headers
+// and returns the body without those fields.
+type headerEncoder func(h http.Header, res any, marshal func(v any) ([]byte, error)) any
+
+type headerField struct {
+	index int
+	name  string
+}
+
+// newHeaderEncoder compiles the header encoder for the response type,
+// returning nil when the type has no header tags.
+func newHeaderEncoder(t reflect.Type) headerEncoder {
+	if t == nil {
+		return nil
+	}
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	headers := []headerField{}
+	omit := map[string]bool{}
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue // skip unexported fields
+		}
+
+		if name, ok := f.Tag.Lookup(headerTag); ok {
+			headers = append(headers, headerField{index: i, name: name})
+			if _, ok := f.Tag.Lookup("json"); !ok {
+				omit[f.Name] = true
+			}
+		}
+	}
+
+	if len(headers) == 0 {
+		return nil
+	}
+
+	return func(h http.Header, res any, marshal func(v any) ([]byte, error)) any {
+		v := reflect.ValueOf(res)
+		if v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				return res
+			}
+			v = v.Elem()
+		}
+
+		for _, hf := range headers {
+			f := v.Field(hf.index)
+			if f.Kind() != reflect.Pointer && f.IsZero() {
+				continue // zero values are not sent unless they are pointers
+			}
+			for _, s := range decoder.Values(f, http.TimeFormat) {
+				h.Add(hf.name, s)
+			}
+		}
+
+		if len(omit) == 0 {
+			return res
+		}
+		return headerBody{v: res, omit: omit, marshal: marshal}
+	}
+}
+
+// unwrapBody returns the response of the body without the header fields left
+// out.
+func unwrapBody(body any) any {
+	if hb, ok := body.(headerBody); ok {
+		return hb.v
+	}
+	return body
+}
+
+// headerBody is the json of the response without the fields only sent as
+// headers. The response is marshaled as is so its MarshalJSON is used.
+type headerBody struct {
+	v       any
+	omit    map[string]bool
+	marshal func(v any) ([]byte, error)
+}
+
+func (b headerBody) MarshalJSON() ([]byte, error) {
+	marshal := b.marshal
+	if codec := jsonCodecFor(reflect.TypeOf(b.v)); codec != nil {
+		marshal = codec.Marshal
+	}
+	if marshal == nil {
+		marshal = json.Marshal
+	}
+
+	data, err := marshal(b.v)
+	if err != nil {
+		return nil, err
+	}
+
+	dec := stdjson.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil || tok != stdjson.Delim('{') {
+		return data, err // not an object e.g. a custom MarshalJSON
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		var val stdjson.RawMessage
+		if err := dec.Decode(&val); err != nil {
+			return nil, err
+		}
+		if b.omit[key.(string)] {
+			continue
+		}
+
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+		k, _ := stdjson.Marshal(key)
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(val)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
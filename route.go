@@ -0,0 +1,125 @@
+package japi
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Route describes a registered route.
+type Route struct {
+	Method  string
+	Path    string
+	Name    string
+	Version string
+
+	// Description describes the route in the OpenAPI document.
+	Description string
+
+	timeout     time.Duration
+	status      int
+	concurrency int
+	idempotency *idempotency
+	coalescer   *coalescer
+	signature   *signature
+	breaker     *breaker
+	encode      *EncodeOptions
+	output      *Output
+	validator   BodyValidator
+	hints       []string
+	params      []paramsHook
+	maxBody     int64
+	fields      *sparseFields
+	example     *routeExample
+	deprecation *deprecation
+
+	responseValidator BodyValidator
+	cacheControl      string
+	contentTypes      []string
+
+	request  reflect.Type
+	response reflect.Type
+
+	disallowUnknown *bool
+}
+
+// RouteOption configures a route when it is registered.
+type RouteOption func(*Route)
+
+// WithTimeout sets a deadline on the handler context and responds with a 504
+// problem when it is exceeded.
+func WithTimeout(d time.Duration) RouteOption {
+	return func(rt *Route) {
+		rt.timeout = d
+	}
+}
+
+// WithConcurrencyLimit limits the requests handled at once by the route and
+// responds with a 503 problem when it is saturated.
+func WithConcurrencyLimit(n int) RouteOption {
+	return func(rt *Route) {
+		rt.concurrency = n
+	}
+}
+
+// Status sets the response status of the route e.g. http.StatusAccepted
+// instead of 200 OK or 201 Created. A StatusCoder response still wins.
+func Status(code int) RouteOption {
+	return func(rt *Route) {
+		rt.status = code
+	}
+}
+
+// StatusNoContent responds with 204 No Content without a body.
+func StatusNoContent() RouteOption {
+	return Status(http.StatusNoContent)
+}
+
+// WithName names the route so its URL can be built with API.URL.
+func WithName(name string) RouteOption {
+	return func(rt *Route) {
+		rt.Name = name
+	}
+}
+
+// URL builds the path for the route using the param key value pairs.
+func (rt *Route) URL(params ...string) (string, error) {
+	if len(params)%2 != 0 {
+		return "", fmt.Errorf("japi: odd number of params for route %q", rt.Path)
+	}
+
+	segments := strings.Split(rt.Path, "/")
+	for i, seg := range segments {
+		if seg == "" || (seg[0] != ':' && seg[0] != '*') {
+			continue
+		}
+
+		key, found := seg[1:], false
+		for j := 0; j < len(params); j += 2 {
+			if params[j] == key {
+				segments[i], found = strings.TrimPrefix(params[j+1], "/"), true
+				if seg[0] == ':' {
+					segments[i] = url.PathEscape(params[j+1])
+				}
+				break
+			}
+		}
+
+		if !found {
+			return "", fmt.Errorf("japi: missing param %q for route %q", key, rt.Path)
+		}
+	}
+
+	return strings.Join(segments, "/"), nil
+}
+
+func newRoute(method, path string, opts []RouteOption) *Route {
+	rt := &Route{Method: method, Path: path}
+	for _, opt := range opts {
+		opt(rt)
+	}
+	return rt
+}
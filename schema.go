@@ -0,0 +1,30 @@
+package japi
+
+import (
+	"io"
+
+	"github.com/jarrettv/go-japi/problem"
+)
+
+// BodyValidator validates a json body e.g. with the JSON Schema of the schema
+// package. The errors are added keyed by the field of the invalid value and
+// the error is returned when the body can't be read or parsed.
+type BodyValidator interface {
+	ValidateBody(body io.Reader, errs problem.Errors) error
+}
+
+// WithRequestValidator validates the json request body with the validator as
+// it is decoded.
+func WithRequestValidator(v BodyValidator) RouteOption {
+	return func(rt *Route) {
+		rt.validator = v
+	}
+}
+
+// WithResponseValidator validates the json responses of the route with the
+// validator when Config.ValidateResponses is enabled.
+func WithResponseValidator(v BodyValidator) RouteOption {
+	return func(rt *Route) {
+		rt.responseValidator = v
+	}
+}
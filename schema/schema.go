@@ -0,0 +1,96 @@
+// Package schema validates the json bodies of japi routes with JSON Schemas so
+// the core package does not depend on a JSON Schema implementation.
+//
+//	r.Post("/users", japi.H(CreateUser), schema.WithRequest(createUserSchema))
+package schema
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+
+	"github.com/jarrettv/go-japi"
+	"github.com/jarrettv/go-japi/problem"
+)
+
+// bodyField is the field of the errors of the whole body, the same as japi.
+const bodyField = "body"
+
+// Schema is a compiled JSON Schema which is a japi.BodyValidator.
+type Schema struct {
+	schema *jsonschema.Schema
+}
+
+var _ japi.BodyValidator = (*Schema)(nil)
+
+// WithRequest validates the json request body with the JSON Schema before it
+// is decoded. The errors are keyed by the JSON Pointer of the invalid value.
+// It panics when the schema is invalid.
+func WithRequest(schema string) japi.RouteOption {
+	return japi.WithRequestValidator(mustCompile("request", schema))
+}
+
+// WithResponse validates the responses of the route with the JSON Schema when
+// Config.ValidateResponses is enabled. It panics when the schema is invalid.
+func WithResponse(schema string) japi.RouteOption {
+	return japi.WithResponseValidator(mustCompile("response", schema))
+}
+
+// Compile compiles the JSON Schema.
+func Compile(schema string) (*Schema, error) {
+	return compile("schema", schema)
+}
+
+func mustCompile(name, schema string) *Schema {
+	s, err := compile(name, schema)
+	if err != nil {
+		panic(fmt.Sprintf("japi: invalid %s schema: %v", name, err))
+	}
+	return s
+}
+
+func compile(name, schema string) (*Schema, error) {
+	doc, err := jsonschema.UnmarshalJSON(strings.NewReader(schema))
+	if err != nil {
+		return nil, err
+	}
+
+	c := jsonschema.NewCompiler()
+	if err := c.AddResource(name+".json", doc); err != nil {
+		return nil, err
+	}
+	s, err := c.Compile(name + ".json")
+	if err != nil {
+		return nil, err
+	}
+	return &Schema{schema: s}, nil
+}
+
+// ValidateBody adds the errors for values of the json that do not match the
+// schema keyed by their JSON Pointer.
+func (s *Schema) ValidateBody(body io.Reader, errs problem.Errors) error {
+	doc, err := jsonschema.UnmarshalJSON(body)
+	if err != nil {
+		return err
+	}
+
+	var ve *jsonschema.ValidationError
+	if err := s.schema.Validate(doc); !errors.As(err, &ve) {
+		return err
+	}
+
+	for _, unit := range ve.BasicOutput().Errors {
+		if unit.Error == nil {
+			continue
+		}
+		field := unit.InstanceLocation
+		if field == "" {
+			field = bodyField
+		}
+		errs.Add(field, unit.Error.String())
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package schema
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jarrettv/go-japi"
+)
+
+type user struct {
+	Name string `json:"name"`
+	Age  int    `json:"age"`
+}
+
+const userSchema = `{"type": "object", "required": ["name"], "properties": {"name": {"type": "string", "minLength": 1}}}`
+
+func TestSchema(t *testing.T) {
+	r := japi.New(nil)
+	r.UpdateConfig(func(c *japi.Config) {
+		c.Logger = nil
+		c.ValidateResponses = true
+	})
+	r.Post("/users", japi.H(func(_ context.Context, u user) (*user, error) {
+		return &u, nil
+	}), WithRequest(userSchema))
+	r.Post("/echo", japi.H(func(_ context.Context, u user) (*user, error) {
+		return &u, nil
+	}), WithResponse(userSchema))
+
+	tests := []struct {
+		name    string
+		path    string
+		body    string
+		status  int
+		contain string
+	}{
+		{"valid", "/users", `{"name":"x","age":1}`, 200, `"name":"x"`},
+		{"invalid", "/users", `{"name":"","age":"x"}`, 400, `"/name":`},
+		{"missing", "/users", `{"age":1}`, 400, `"body":`},
+		{"valid response", "/echo", `{"name":"x"}`, 200, `"name":"x"`},
+		{"invalid response", "/echo", `{"age":1}`, 500, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", japi.JsonEncoding)
+			w := httptest.NewRecorder()
+			r.Router().ServeHTTP(w, req)
+
+			if w.Code != tt.status {
+				t.Fatalf("status %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+			if !strings.Contains(w.Body.String(), tt.contain) {
+				t.Errorf("body %s does not contain %s", w.Body, tt.contain)
+			}
+		})
+	}
+}
+
+func TestWithRequestInvalid(t *testing.T) {
+	defer func() {
+		if v := recover(); v == nil || !strings.HasPrefix(v.(string), "japi: invalid request schema") {
+			t.Errorf("panic %v, want an invalid request schema", v)
+		}
+	}()
+	WithRequest(`{"type": 1}`)
+}
@@ -0,0 +1,107 @@
+package japi
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SecurityOption configures the security headers.
+type SecurityOption func(*securityConfig)
+
+type securityConfig struct {
+	hsts           time.Duration
+	subdomains     bool
+	frameOptions   string
+	referrerPolicy string
+	csp            string
+	spaPrefix      string
+	spaCSP         string
+}
+
+// SecurityHSTS sets the Strict-Transport-Security max age. A zero max age
+// removes the header.
+func SecurityHSTS(maxAge time.Duration, includeSubdomains bool) SecurityOption {
+	return func(c *securityConfig) {
+		c.hsts, c.subdomains = maxAge, includeSubdomains
+	}
+}
+
+// SecurityFrameOptions sets the X-Frame-Options header. An empty value removes
+// the header.
+func SecurityFrameOptions(value string) SecurityOption {
+	return func(c *securityConfig) {
+		c.frameOptions = value
+	}
+}
+
+// SecurityReferrerPolicy sets the Referrer-Policy header. An empty value
+// removes the header.
+func SecurityReferrerPolicy(value string) SecurityOption {
+	return func(c *securityConfig) {
+		c.referrerPolicy = value
+	}
+}
+
+// SecurityCSP sets the Content-Security-Policy of the API responses. An empty
+// policy removes the header.
+func SecurityCSP(policy string) SecurityOption {
+	return func(c *securityConfig) {
+		c.csp = policy
+	}
+}
+
+// SecuritySPA sets the Content-Security-Policy of the paths under the prefix
+// e.g. a single page app served next to the API.
+func SecuritySPA(prefix, policy string) SecurityOption {
+	return func(c *securityConfig) {
+		c.spaPrefix, c.spaCSP = prefix, policy
+	}
+}
+
+// SecurityHeaders returns a middleware that sets the standard security headers.
+// The defaults suit a JSON API: HSTS for two years, nosniff, frames denied, no
+// referrer and a policy that loads nothing.
+func SecurityHeaders(opts ...SecurityOption) Middleware {
+	c := &securityConfig{
+		hsts:           2 * 365 * 24 * time.Hour,
+		subdomains:     true,
+		frameOptions:   "DENY",
+		referrerPolicy: "no-referrer",
+		csp:            "default-src 'none'; frame-ancestors 'none'",
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	hsts := ""
+	if c.hsts > 0 {
+		hsts = "max-age=" + strconv.Itoa(int(c.hsts/time.Second))
+		if c.subdomains {
+			hsts += "; includeSubDomains"
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			h := w.Header()
+			h.Set("X-Content-Type-Options", "nosniff")
+			setHeader(h, "Strict-Transport-Security", hsts)
+			setHeader(h, "X-Frame-Options", c.frameOptions)
+			setHeader(h, "Referrer-Policy", c.referrerPolicy)
+			if c.spaPrefix != "" && strings.HasPrefix(r.URL.Path, c.spaPrefix) {
+				setHeader(h, "Content-Security-Policy", c.spaCSP)
+			} else {
+				setHeader(h, "Content-Security-Policy", c.csp)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func setHeader(h http.Header, key, value string) {
+	if value != "" {
+		h.Set(key, value)
+	}
+}
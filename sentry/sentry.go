@@ -0,0 +1,52 @@
+// Package sentry reports the 5xx problems of japi to Sentry.
+//
+//	r := japi.New(&japi.Config{ErrorReporter: sentry.NewReporter()})
+package sentry
+
+import (
+	"context"
+
+	"github.com/getsentry/sentry-go"
+
+	"github.com/jarrettv/go-japi"
+	"github.com/jarrettv/go-japi/problem"
+)
+
+// Reporter is a japi.ErrorReporter backed by Sentry.
+type Reporter struct {
+	hub *sentry.Hub
+}
+
+// NewReporter creates a japi.ErrorReporter using the current hub. Initialize
+// the client with sentry.Init first.
+func NewReporter() *Reporter {
+	return NewReporterWith(sentry.CurrentHub())
+}
+
+// NewReporterWith creates a japi.ErrorReporter using the hub.
+func NewReporterWith(hub *sentry.Hub) *Reporter {
+	return &Reporter{hub: hub}
+}
+
+// Report captures the error with the problem and request in the scope. The hub
+// of the context is used when set by the sentryhttp middleware.
+func (r *Reporter) Report(ctx context.Context, err error, p *problem.Problem) {
+	hub := sentry.GetHubFromContext(ctx)
+	if hub == nil {
+		hub = r.hub.Clone()
+	}
+
+	hub.WithScope(func(scope *sentry.Scope) {
+		scope.SetTag("problem.type", p.Type)
+		scope.SetContext("problem", sentry.Context{
+			"title":    p.Title,
+			"status":   p.Status,
+			"detail":   p.Detail,
+			"instance": p.Instance,
+		})
+		if req := japi.RequestFrom(ctx); req != nil {
+			scope.SetRequest(req)
+		}
+		hub.CaptureException(err)
+	})
+}
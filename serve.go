@@ -0,0 +1,108 @@
+package japi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ShutdownTimeout is how long Serve waits for the requests in flight to finish
+// when shutting down.
+var ShutdownTimeout = 30 * time.Second
+
+// Serve listens on the server address with the API router as the handler when
+// the server has none and runs the cron schedules, including those of the
+// mounted and hosted APIs. When the context is done it stops the schedules and
+// shuts down gracefully, waiting for the requests in flight, including
+// hijacked connections e.g. websockets, and the running cron funcs. The
+// requests still in flight after the DrainTimeout are cancelled with
+// ErrShutdown through the BaseContext of the server, which Serve sets, and
+// their errors respond with 503 problems.
+func (r *API) Serve(ctx context.Context, srv *http.Server) error {
+	if srv.Handler == nil {
+		srv.Handler = r.Router()
+	}
+	base, cancelRequests := cancelContext(srv)
+	srv.BaseContext = base
+	defer cancelRequests(nil)
+
+	apis := r.apis()
+
+	cronCtx, stopCron := context.WithCancel(ctx)
+	defer stopCron()
+	var cron sync.WaitGroup
+	for _, a := range apis {
+		a.startCron(cronCtx, &cron)
+	}
+
+	served := make(chan error, 1)
+	go func() {
+		served <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-served:
+		stopCron()
+		cron.Wait()
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), ShutdownTimeout)
+	defer cancel()
+	shutdown := make(chan error, 1)
+	go func() {
+		shutdown <- srv.Shutdown(shutdownCtx)
+	}()
+
+	drainCtx, cancelDrain := context.WithTimeout(shutdownCtx, DrainTimeout)
+	defer cancelDrain()
+	if waitInFlight(drainCtx, apis) != nil {
+		cancelRequests(ErrShutdown)
+		for _, a := range apis {
+			a.logCancelled(shutdownCtx)
+		}
+	}
+
+	err := <-shutdown
+	if err == nil {
+		err = waitInFlight(shutdownCtx, apis)
+	}
+	cron.Wait()
+	if serveErr := <-served; !errors.Is(serveErr, http.ErrServerClosed) && err == nil {
+		err = serveErr
+	}
+	return err
+}
+
+// apis returns the API and the APIs mounted or hosted by it.
+func (r *API) apis() []*API {
+	seen := map[*API]bool{}
+	apis := []*API{}
+	var walk func(a *API)
+	walk = func(a *API) {
+		if seen[a] {
+			return
+		}
+		seen[a] = true
+		apis = append(apis, a)
+		for _, child := range a.children {
+			walk(child)
+		}
+	}
+	walk(r)
+	return apis
+}
+
+// waitInFlight waits until the APIs have no requests in flight or the context
+// is done.
+func waitInFlight(ctx context.Context, apis []*API) error {
+	for _, a := range apis {
+		if err := a.tracker.wait(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package japi
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/jarrettv/go-japi/problem"
+)
+
+// ErrShutdown is the cause of the request contexts cancelled by Serve when the
+// requests are still in flight after the DrainTimeout.
+var ErrShutdown = errors.New("japi: server is shutting down")
+
+// DrainTimeout is how long Serve lets the requests in flight finish when
+// shutting down before it cancels them with ErrShutdown. The rest of the
+// ShutdownTimeout is left for them to respond.
+var DrainTimeout = 25 * time.Second
+
+var shuttingDown = problem.Register("shutting-down", http.StatusServiceUnavailable, "Shutting down").
+	Describe("The server shut down before the request finished, retry the request")
+
+// isShutdown reports whether the context was cancelled by the shutdown.
+func isShutdown(ctx context.Context) bool {
+	return errors.Is(context.Cause(ctx), ErrShutdown)
+}
+
+// shutdownProblem closes the connection so the client retries on another one.
+func shutdownProblem() *problem.Problem {
+	return shuttingDown.New("").WithHeader("Connection", "close")
+}
+
+// cancelContext returns the base context of the server which cancels the
+// contexts of all its requests with the cause. The base context of the server
+// is kept as the parent.
+func cancelContext(srv *http.Server) (base func(net.Listener) context.Context, cancel context.CancelCauseFunc) {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	parent := srv.BaseContext
+	if parent == nil {
+		return func(net.Listener) context.Context { return ctx }, cancel
+	}
+
+	return func(l net.Listener) context.Context {
+		lctx, lcancel := context.WithCancelCause(parent(l))
+		context.AfterFunc(ctx, func() { lcancel(context.Cause(ctx)) })
+		return lctx
+	}, cancel
+}
+
+// logCancelled logs the routes of the requests cancelled by the shutdown,
+// with their duration when Config.TrackRequests is enabled.
+func (r *API) logCancelled(ctx context.Context) {
+	c := r.config.Load()
+	if c.Logger == nil {
+		return
+	}
+
+	if c.TrackRequests {
+		now := time.Now()
+		for _, req := range r.tracker.tracked() {
+			c.Logger.LogAttrs(ctx, slog.LevelWarn, "request cancelled by shutdown",
+				slog.String("route", req.route), slog.Duration("duration", now.Sub(req.start)))
+		}
+		return
+	}
+
+	for route, n := range r.tracker.stats().Routes {
+		c.Logger.LogAttrs(ctx, slog.LevelWarn, "requests cancelled by shutdown",
+			slog.String("route", route), slog.Int("count", n))
+	}
+}
@@ -0,0 +1,167 @@
+package japi
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/jarrettv/go-japi/problem"
+)
+
+var invalidSignature = problem.Register("invalid-signature", http.StatusUnauthorized, "Invalid signature").
+	Describe("The request signature header is missing or does not match the body")
+
+// SignatureOption configures the signature verification.
+type SignatureOption func(*signature)
+
+// KeyFunc returns the secret key of the signed request. A nil key responds with
+// a 401 problem.
+type KeyFunc func(r *http.Request) ([]byte, error)
+
+type signature struct {
+	header     string
+	key        KeyFunc
+	algorithms map[string]func() hash.Hash
+	decode     func(string) ([]byte, error)
+
+	timestampHeader string
+	tolerance       time.Duration
+}
+
+// SignatureAlgorithm accepts signatures with the prefix e.g. sha512= using the
+// hash e.g. sha512.New. The first algorithm option replaces the sha256= default.
+func SignatureAlgorithm(prefix string, h func() hash.Hash) SignatureOption {
+	return func(s *signature) {
+		if s.algorithms == nil {
+			s.algorithms = map[string]func() hash.Hash{}
+		}
+		s.algorithms[prefix] = h
+	}
+}
+
+// SignatureEncoding decodes the signatures e.g. base64.StdEncoding.DecodeString.
+// Signatures are hex by default.
+func SignatureEncoding(decode func(string) ([]byte, error)) SignatureOption {
+	return func(s *signature) {
+		s.decode = decode
+	}
+}
+
+// SignatureTimestamp verifies the signatures over the unix seconds in the
+// header, a dot and the raw body so a captured request cannot be replayed once
+// the timestamp is older than the tolerance. Requests without a timestamp or
+// one off by more than the tolerance respond with a 401 problem.
+func SignatureTimestamp(header string, tolerance time.Duration) SignatureOption {
+	return func(s *signature) {
+		s.timestampHeader, s.tolerance = header, tolerance
+	}
+}
+
+// WithSignature verifies the HMAC signature in the header over the raw body
+// before the route is handled, responding with a 401 problem on a mismatch.
+// The header may have several comma separated signatures e.g. during key
+// rotation and any match is accepted. The body is buffered so it is still
+// decoded afterwards.
+func WithSignature(header string, key KeyFunc, opts ...SignatureOption) RouteOption {
+	s := &signature{header: header, key: key, decode: hex.DecodeString}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.algorithms == nil {
+		s.algorithms = map[string]func() hash.Hash{"sha256=": sha256.New}
+	}
+
+	return func(rt *Route) {
+		rt.signature = s
+	}
+}
+
+// verify wraps the handle to check the request signature. The body is read
+// up to the body limit of the route.
+func verify(ref *configRef, rt *Route, s *signature, next httprouter.Handle) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+		c := ref.Load().forTenant(r.Context())
+
+		if !limitBody(w, r, rt.maxBodyBytes(c)) {
+			c.serveProblem(w, r, problem.Status(http.StatusRequestEntityTooLarge))
+			return
+		}
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			if isTooLarge(err) {
+				c.serveProblem(w, r, problem.Status(http.StatusRequestEntityTooLarge))
+			} else {
+				c.serveProblem(w, r, problem.BadRequest(err))
+			}
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		key, err := s.key(r)
+		if err != nil {
+			pd, ok := asProblem(err)
+			if !ok {
+				pd = problem.Unexpected(err)
+			}
+			c.serveProblem(w, r, pd)
+			return
+		}
+
+		signed, ok := s.signed(r, body)
+		if !ok || key == nil || !s.valid(r.Header.Get(s.header), key, signed) {
+			c.serveProblem(w, r, invalidSignature.New(""))
+			return
+		}
+		next(w, r, p)
+	}
+}
+
+// signed returns the signed content of the request, which is the body unless
+// the signature has a timestamp within the tolerance.
+func (s *signature) signed(r *http.Request, body []byte) ([]byte, bool) {
+	if s.timestampHeader == "" {
+		return body, true
+	}
+
+	ts := r.Header.Get(s.timestampHeader)
+	sec, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return nil, false
+	}
+	if age := time.Since(time.Unix(sec, 0)); age > s.tolerance || age < -s.tolerance {
+		return nil, false
+	}
+	return append([]byte(ts+"."), body...), true
+}
+
+// valid reports whether any signature of the header value matches the body.
+func (s *signature) valid(value string, key, body []byte) bool {
+	for _, sig := range strings.Split(value, ",") {
+		sig = strings.TrimSpace(sig)
+		for prefix, h := range s.algorithms {
+			if !strings.HasPrefix(sig, prefix) {
+				continue
+			}
+
+			want, err := s.decode(sig[len(prefix):])
+			if err != nil {
+				continue
+			}
+			mac := hmac.New(h, key)
+			mac.Write(body)
+			if hmac.Equal(mac.Sum(nil), want) {
+				return true
+			}
+		}
+	}
+	return false
+}
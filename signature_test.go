@@ -0,0 +1,84 @@
+package japi
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func sign(key []byte, content string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(content))
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestWithSignature(t *testing.T) {
+	key, old := []byte("new-secret"), []byte("old-secret")
+	body := `{"name":"x"}`
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	stale := strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10)
+
+	r := New(nil)
+	r.UpdateConfig(func(c *Config) { c.Logger = nil })
+	keyFunc := func(*http.Request) ([]byte, error) { return key, nil }
+	handle := H(func(_ context.Context, req itemsRequest) (*itemsRequest, error) {
+		return &req, nil
+	})
+	r.Post("/hooks", handle, WithSignature("X-Signature", keyFunc))
+	r.Post("/timed", handle, WithSignature("X-Signature", keyFunc, SignatureTimestamp("X-Timestamp", time.Minute)))
+	h := r.Router()
+
+	tests := []struct {
+		name      string
+		path      string
+		signature string
+		timestamp string
+		status    int
+	}{
+		{"valid", "/hooks", sign(key, body), "", 200},
+		{"missing", "/hooks", "", "", 401},
+		{"mismatch", "/hooks", sign(key, `{"name":"y"}`), "", 401},
+		{"wrong key", "/hooks", sign(old, body), "", 401},
+		{"unknown algorithm", "/hooks", "sha1=" + sign(key, body)[len("sha256="):], "", 401},
+		{"not hex", "/hooks", "sha256=zz", "", 401},
+		{"rotated key", "/hooks", sign(old, body) + ", " + sign(key, body), "", 200},
+		{"rotated key first", "/hooks", sign(key, body) + "," + sign(old, body), "", 200},
+		{"timestamp", "/timed", sign(key, now+"."+body), now, 200},
+		{"timestamp missing", "/timed", sign(key, now+"."+body), "", 401},
+		{"timestamp stale", "/timed", sign(key, stale+"."+body), stale, 401},
+		{"timestamp replaced", "/timed", sign(key, stale+"."+body), now, 401},
+		{"timestamp not signed", "/timed", sign(key, body), now, 401},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", tt.path, strings.NewReader(body))
+			req.Header.Set("Content-Type", JsonEncoding)
+			if tt.signature != "" {
+				req.Header.Set("X-Signature", tt.signature)
+			}
+			if tt.timestamp != "" {
+				req.Header.Set("X-Timestamp", tt.timestamp)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+
+			if w.Code != tt.status {
+				t.Fatalf("status %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+			if tt.status == 200 && !strings.Contains(w.Body.String(), `"name":"x"`) {
+				t.Errorf("body %s is not the decoded request", w.Body)
+			}
+			if tt.status == 401 && !strings.Contains(w.Body.String(), "invalid-signature") {
+				t.Errorf("body %s is not the invalid signature problem", w.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package japi
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/jarrettv/go-japi/problem"
+)
+
+// routeParams returns the path params of the route by key.
+func routeParams(route string, p httprouter.Params) map[string]string {
+	vars := make(map[string]string, len(p))
+	for _, param := range p {
+		if param.Value != route {
+			vars[param.Key] = param.Value
+		}
+	}
+	return vars
+}
+
+// logRequest logs the handled request when there is no RouteLogFunc.
+func (c *Config) logRequest(r *http.Request, route string, p httprouter.Params, start time.Time, status int) {
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	attrs := []slog.Attr{
+		slog.String("method", r.Method),
+		slog.String("route", route),
+		slog.String("ip", ClientIP(r.Context())),
+		slog.Int("status", status),
+		slog.Duration("duration", time.Since(start)),
+	}
+	if tenant := Tenant(r.Context()); tenant != "" {
+		attrs = append(attrs, slog.String("tenant", tenant))
+	}
+	if len(p) > 0 {
+		params := make([]slog.Attr, 0, len(p))
+		for _, param := range p {
+			if param.Value != route {
+				params = append(params, slog.String(param.Key, param.Value))
+			}
+		}
+		if len(params) > 0 {
+			attrs = append(attrs, slog.GroupAttrs("params", params...))
+		}
+	}
+	c.Logger.LogAttrs(r.Context(), slog.LevelInfo, "request", attrs...)
+}
+
+// logProblem logs the problem when there is no ProblemLogFunc. Server
+// problems are logged as errors and client problems as warnings.
+func (c *Config) logProblem(ctx context.Context, p *problem.Problem) {
+	level := slog.LevelWarn
+	if p.Status >= http.StatusInternalServerError {
+		level = slog.LevelError
+	}
+
+	attrs := []slog.Attr{
+		slog.String("type", p.Type),
+		slog.Int("status", p.Status),
+	}
+	if p.Detail != "" {
+		attrs = append(attrs, slog.String("detail", p.Detail))
+	}
+	if p.Instance != "" {
+		attrs = append(attrs, slog.String("instance", p.Instance))
+	}
+	logAttrs := []slog.Attr{slog.GroupAttrs("problem", attrs...)}
+	if tenant := Tenant(ctx); tenant != "" {
+		logAttrs = append(logAttrs, slog.String("tenant", tenant))
+	}
+	c.Logger.LogAttrs(ctx, level, p.Title, logAttrs...)
+}
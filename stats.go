@@ -0,0 +1,174 @@
+package japi
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// Stats are the requests in flight of the API.
+type Stats struct {
+	// InFlight is the number of requests being handled.
+	InFlight int `json:"inFlight"`
+	// Routes is the number of requests being handled by method and route.
+	Routes map[string]int `json:"routes"`
+	// Oldest is the age of the oldest request being handled, which is only
+	// known when Config.TrackRequests is enabled.
+	Oldest time.Duration `json:"oldest"`
+}
+
+// tracker counts the requests in flight with atomic counters so the routes do
+// not contend. The start of each request is only kept when
+// Config.TrackRequests is enabled.
+type tracker struct {
+	inFlight atomic.Int64
+	waiting  atomic.Bool
+
+	mu      sync.Mutex
+	routes  map[string]*atomic.Int64
+	next    uint64
+	active  map[uint64]activeRequest
+	drained chan struct{}
+}
+
+type activeRequest struct {
+	route string
+	start time.Time
+}
+
+func newTracker() *tracker {
+	return &tracker{routes: map[string]*atomic.Int64{}, active: map[uint64]activeRequest{}}
+}
+
+// track wraps the handle to count the requests of the route and limit the
+// requests of the API to the semaphore of Config.MaxInFlight when it is set.
+func (r *API) track(route string, next httprouter.Handle) httprouter.Handle {
+	t := r.tracker
+	count := t.route(route)
+
+	return func(w http.ResponseWriter, req *http.Request, p httprouter.Params) {
+		if sem := *r.inFlight.Load(); sem != nil {
+			if !acquire(r.config, sem, w, req) {
+				return
+			}
+			defer func() { <-sem }()
+		}
+
+		t.inFlight.Add(1)
+		count.Add(1)
+		defer t.done(count)
+
+		if r.config.Load().TrackRequests {
+			id := t.add(route)
+			defer t.remove(id)
+		}
+		next(w, req, p)
+	}
+}
+
+// route returns the counter of the route.
+func (t *tracker) route(route string) *atomic.Int64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	count := t.routes[route]
+	if count == nil {
+		count = &atomic.Int64{}
+		t.routes[route] = count
+	}
+	return count
+}
+
+// done counts the end of a request and signals the waiter when it was the
+// last in flight.
+func (t *tracker) done(count *atomic.Int64) {
+	count.Add(-1)
+	if t.inFlight.Add(-1) != 0 || !t.waiting.Load() {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.drained != nil && t.inFlight.Load() == 0 {
+		close(t.drained)
+		t.drained = nil
+	}
+}
+
+func (t *tracker) add(route string) uint64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.next++
+	t.active[t.next] = activeRequest{route: route, start: time.Now()}
+	return t.next
+}
+
+func (t *tracker) remove(id uint64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.active, id)
+}
+
+func (t *tracker) stats() Stats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	s := Stats{InFlight: int(t.inFlight.Load()), Routes: map[string]int{}}
+	for route, count := range t.routes {
+		if n := count.Load(); n > 0 {
+			s.Routes[route] = int(n)
+		}
+	}
+	now := time.Now()
+	for _, req := range t.active {
+		if age := now.Sub(req.start); age > s.Oldest {
+			s.Oldest = age
+		}
+	}
+	return s
+}
+
+// tracked returns the requests in flight with their start.
+func (t *tracker) tracked() []activeRequest {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	active := make([]activeRequest, 0, len(t.active))
+	for _, req := range t.active {
+		active = append(active, req)
+	}
+	return active
+}
+
+// wait waits until there are no requests in flight or the context is done.
+func (t *tracker) wait(ctx context.Context) error {
+	t.mu.Lock()
+	t.waiting.Store(true)
+	if t.inFlight.Load() == 0 {
+		t.mu.Unlock()
+		return nil
+	}
+	if t.drained == nil {
+		t.drained = make(chan struct{})
+	}
+	drained := t.drained
+	t.mu.Unlock()
+
+	select {
+	case <-drained:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Stats returns the requests in flight of the routes.
+func (r *API) Stats() Stats {
+	return r.tracker.stats()
+}
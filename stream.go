@@ -0,0 +1,123 @@
+package japi
+
+import (
+	"bytes"
+	"context"
+	"iter"
+	"net/http"
+	"time"
+)
+
+// StreamFlushInterval is the max time the streamed items are buffered before
+// they are flushed to the client.
+var StreamFlushInterval = 100 * time.Millisecond
+
+// streamBuffer is the size in bytes of the streamed items written at once.
+const streamBuffer = 32 << 10
+
+// Iter is a response streamed as a json array one item at a time so large
+// collections are not built in memory. It is collected into a slice when the
+// response is wrapped, shaped by an output or encoded by a registered encoder.
+type Iter[T any] struct {
+	seq iter.Seq2[T, error]
+}
+
+// streamer is implemented by Iter.
+type streamer interface {
+	streamJSON(w http.ResponseWriter, r *http.Request, opts EncodeOptions) (bool, error)
+	collect(ctx context.Context) (any, error)
+}
+
+// Stream returns the response that streams the items of the sequence.
+func Stream[T any](seq iter.Seq[T]) *Iter[T] {
+	return &Iter[T]{seq: func(yield func(T, error) bool) {
+		for v := range seq {
+			if !yield(v, nil) {
+				return
+			}
+		}
+	}}
+}
+
+// StreamErr returns the response that streams the items of the sequence until
+// it yields an error.
+func StreamErr[T any](seq iter.Seq2[T, error]) *Iter[T] {
+	return &Iter[T]{seq: seq}
+}
+
+// StreamChan returns the response that streams the items received until the
+// channel is closed. The sender should stop when the request context is done.
+func StreamChan[T any](ch <-chan T) *Iter[T] {
+	return &Iter[T]{seq: func(yield func(T, error) bool) {
+		for v := range ch {
+			if !yield(v, nil) {
+				return
+			}
+		}
+	}}
+}
+
+// streamJSON writes the items flushing them periodically. It returns whether
+// the response was started so errors after it cannot be served as problems.
+func (it *Iter[T]) streamJSON(w http.ResponseWriter, r *http.Request, opts EncodeOptions) (bool, error) {
+	ctx := r.Context()
+	flusher, _ := w.(http.Flusher)
+	started, flushed := false, time.Now()
+
+	write := func(flush bool, buf *bytes.Buffer) error {
+		started = true
+		if _, err := w.Write(buf.Bytes()); err != nil {
+			return err
+		}
+		buf.Reset()
+		if flush && flusher != nil {
+			flusher.Flush()
+			flushed = time.Now()
+		}
+		return nil
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte('[')
+	n := 0
+	for v, err := range it.seq {
+		if err == nil {
+			err = ctx.Err()
+		}
+		if err != nil {
+			return started, err
+		}
+
+		if n > 0 {
+			buf.WriteByte(',')
+		}
+		if err := encodeJSON(&buf, r, v, opts); err != nil {
+			return started, err
+		}
+		buf.Truncate(buf.Len() - 1) // remove the newline
+		n++
+
+		if due := time.Since(flushed) >= StreamFlushInterval; due || buf.Len() >= streamBuffer {
+			if err := write(due, &buf); err != nil {
+				return started, err
+			}
+		}
+	}
+
+	buf.WriteString("]\n")
+	return true, write(false, &buf)
+}
+
+func (it *Iter[T]) collect(ctx context.Context) (any, error) {
+	items := []T{}
+	for v, err := range it.seq {
+		if err == nil {
+			err = ctx.Err()
+		}
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, v)
+	}
+	return items, nil
+}
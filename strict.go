@@ -0,0 +1,157 @@
+package japi
+
+import (
+	stdjson "encoding/json"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/goccy/go-json"
+
+	"github.com/jarrettv/go-japi/problem"
+)
+
+var unmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// WithDisallowUnknownFields overrides Config.DisallowUnknownFields for the route.
+func WithDisallowUnknownFields(disallow bool) RouteOption {
+	return func(rt *Route) {
+		rt.disallowUnknown = &disallow
+	}
+}
+
+// disallowUnknownFields returns the setting of the route or the config. The
+// route may be nil.
+func (rt *Route) disallowUnknownFields(config *Config) bool {
+	if rt != nil && rt.disallowUnknown != nil {
+		return *rt.disallowUnknown
+	}
+	return config.DisallowUnknownFields
+}
+
+// addUnknownFields adds the errors for the fields of the json that are not in
+// the type. The json is walked one token at a time so it is not buffered.
+func addUnknownFields(body io.Reader, t reflect.Type, errs problem.Errors) error {
+	dec := stdjson.NewDecoder(body)
+	dec.UseNumber()
+
+	unknown := []string{}
+	if err := unknownFields(dec, t, "", &unknown); err != nil {
+		return err
+	}
+	if _, err := dec.Token(); err != nil && err != io.EOF {
+		return err
+	}
+
+	sort.Strings(unknown)
+	for i, f := range unknown {
+		if i > 0 && unknown[i-1] == f {
+			continue
+		}
+		if f == "" {
+			f = bodyField // the field with an empty name
+		}
+		errs.Add(f, "unknown field")
+	}
+
+	return nil
+}
+
+// unknownFields walks the next value of the decoder and collects the fields
+// that are not in the type. A nil type is not checked since the value does
+// not match the type or is decoded by an unmarshaler.
+func unknownFields(dec *stdjson.Decoder, t reflect.Type, prefix string, unknown *[]string) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	delim, ok := tok.(stdjson.Delim)
+	if !ok {
+		return nil
+	}
+
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t != nil && reflect.PointerTo(t).Implements(unmarshalerType) {
+		t = nil
+	}
+
+	switch delim {
+	case '{':
+		var fields map[string]reflect.Type
+		if t != nil && t.Kind() == reflect.Struct {
+			fields = map[string]reflect.Type{}
+			jsonFields(t, fields)
+		}
+
+		for dec.More() {
+			tok, err := dec.Token()
+			if err != nil {
+				return err
+			}
+			k, _ := tok.(string)
+
+			var et reflect.Type
+			switch {
+			case fields != nil:
+				if et = fields[strings.ToLower(k)]; et == nil {
+					*unknown = append(*unknown, prefix+k)
+				}
+			case t != nil && t.Kind() == reflect.Map:
+				et = t.Elem()
+			}
+			if err := unknownFields(dec, et, prefix+k+".", unknown); err != nil {
+				return err
+			}
+		}
+	case '[':
+		var et reflect.Type
+		if t != nil && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) {
+			et = t.Elem()
+		}
+
+		for i := 0; dec.More(); i++ {
+			if err := unknownFields(dec, et, prefix+strconv.Itoa(i)+".", unknown); err != nil {
+				return err
+			}
+		}
+	}
+
+	_, err = dec.Token() // the closing delim
+	return err
+}
+
+// jsonFields collects the json field names of the struct in lower case.
+func jsonFields(t reflect.Type, fields map[string]reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		name, _, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				jsonFields(ft, fields)
+				continue
+			}
+		}
+
+		if f.PkgPath != "" {
+			continue // skip unexported fields
+		}
+
+		if name == "" {
+			name = f.Name
+		}
+		fields[strings.ToLower(name)] = f.Type
+	}
+}
@@ -6,6 +6,9 @@ import (
 
 func hasTag(v interface{}, tag string) bool {
 	t := reflect.TypeOf(v)
+	if t == nil {
+		return false // interface types
+	}
 
 	if t.Kind() == reflect.Ptr {
 		t = t.Elem()
@@ -19,6 +22,17 @@ func hasTag(v interface{}, tag string) bool {
 }
 
 func typeHasTag(t reflect.Type, tag string) bool {
+	return structHasTag(t, tag, map[reflect.Type]bool{})
+}
+
+// structHasTag checks the fields of the struct and its nested structs. The
+// seen types stop recursive types.
+func structHasTag(t reflect.Type, tag string, seen map[reflect.Type]bool) bool {
+	if seen[t] {
+		return false
+	}
+	seen[t] = true
+
 	for i := 0; i < t.NumField(); i++ {
 		f := t.Field(i)
 
@@ -36,7 +50,7 @@ func typeHasTag(t reflect.Type, tag string) bool {
 		}
 
 		if ft.Kind() == reflect.Struct {
-			if typeHasTag(ft, tag) {
+			if structHasTag(ft, tag, seen) {
 				return true
 			}
 		}
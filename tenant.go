@@ -0,0 +1,263 @@
+package japi
+
+import (
+	"container/list"
+	"context"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/jarrettv/go-japi/problem"
+)
+
+var tenantRequired = problem.Register("tenant-required", http.StatusBadRequest, "Tenant required").
+	Describe("The tenant of the request could not be resolved")
+
+// TenantResolver returns the tenant of the request or an empty string.
+type TenantResolver func(r *http.Request) string
+
+// Tenancy resolves the tenant of each request before the middleware runs.
+type Tenancy struct {
+	// Resolvers are tried in order and the first tenant found is used.
+	Resolvers []TenantResolver
+	// Required responds with a 400 problem when no tenant is found.
+	Required bool
+	// Override changes a copy of the config for the requests of the tenant e.g.
+	// the problem instance URLs. The copy is kept until the config is updated.
+	Override func(tenant string, c *Config)
+	// Middleware returns the middleware of the tenant e.g. its rate limiter.
+	// It is called once for each tenant kept.
+	Middleware func(tenant string) []Middleware
+	// MaxTenants is the number of tenants whose config and middleware are
+	// kept, the least recently seen are dropped. Defaults to 1000. The tenants
+	// are resolved from the request so resolve only known tenants when their
+	// middleware has state that must not be dropped.
+	MaxTenants int
+
+	configs tenantCache[*tenantConfig]
+}
+
+func (t *Tenancy) maxTenants() int {
+	if t.MaxTenants <= 0 {
+		return 1000
+	}
+	return t.MaxTenants
+}
+
+// tenantConfig is the config of a tenant derived from the base config.
+type tenantConfig struct {
+	base *Config
+	c    *Config
+}
+
+type tenantKey struct{}
+
+type tenantValue struct {
+	tenant string
+	config *tenantConfig
+}
+
+// Tenant returns the tenant of the request resolved with Config.Tenancy.
+func Tenant(ctx context.Context) string {
+	v, _ := ctx.Value(tenantKey{}).(*tenantValue)
+	if v == nil {
+		return ""
+	}
+	return v.tenant
+}
+
+// withTenant resolves the tenant and applies the tenant middleware. The
+// middleware of each tenant is built once and kept with the tenancy it was
+// built for.
+func withTenant(ref *configRef, next http.Handler) http.Handler {
+	var (
+		mu     sync.Mutex
+		owner  *Tenancy
+		chains *tenantCache[http.Handler]
+	)
+	chain := func(t *Tenancy, tenant string) http.Handler {
+		mu.Lock()
+		if owner != t {
+			owner, chains = t, &tenantCache[http.Handler]{}
+		}
+		cache := chains
+		mu.Unlock()
+
+		return cache.get(tenant, t.maxTenants(), nil, func() http.Handler {
+			h := next
+			mw := t.Middleware(tenant)
+			for i := len(mw) - 1; i >= 0; i-- {
+				h = mw[i](h)
+			}
+			return h
+		})
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c := ref.Load()
+		t := c.Tenancy
+		if t == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		tenant := t.resolve(r)
+		if tenant == "" {
+			if t.Required {
+				c.serveProblem(w, r, tenantRequired.New(""))
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		r = r.WithContext(context.WithValue(r.Context(), tenantKey{}, &tenantValue{
+			tenant: tenant,
+			config: t.config(c, tenant),
+		}))
+
+		h := next
+		if t.Middleware != nil {
+			h = chain(t, tenant)
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+func (t *Tenancy) resolve(r *http.Request) string {
+	for _, resolve := range t.Resolvers {
+		if tenant := resolve(r); tenant != "" {
+			return tenant
+		}
+	}
+	return ""
+}
+
+// config returns the config of the tenant, nil without an override.
+func (t *Tenancy) config(base *Config, tenant string) *tenantConfig {
+	if t.Override == nil {
+		return nil
+	}
+
+	return t.configs.get(tenant, t.maxTenants(), func(tc *tenantConfig) bool {
+		return tc.base == base
+	}, func() *tenantConfig {
+		c := *base
+		t.Override(tenant, &c)
+		return &tenantConfig{base: base, c: &c}
+	})
+}
+
+// tenantCache keeps the values of the most recently seen tenants.
+type tenantCache[V any] struct {
+	mu    sync.Mutex
+	order list.List // of *tenantEntry[V], the most recent first
+	items map[string]*list.Element
+}
+
+type tenantEntry[V any] struct {
+	tenant string
+	value  V
+}
+
+// get returns the value of the tenant, creating it when it is not kept or
+// not valid. The least recently seen tenant is dropped past the max.
+func (c *tenantCache[V]) get(tenant string, max int, valid func(V) bool, create func() V) V {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[tenant]; ok {
+		e := el.Value.(*tenantEntry[V])
+		if valid == nil || valid(e.value) {
+			c.order.MoveToFront(el)
+			return e.value
+		}
+		c.order.Remove(el)
+		delete(c.items, tenant)
+	}
+
+	if c.items == nil {
+		c.items = map[string]*list.Element{}
+	}
+	e := &tenantEntry[V]{tenant: tenant, value: create()}
+	c.items[tenant] = c.order.PushFront(e)
+	for c.order.Len() > max {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*tenantEntry[V]).tenant)
+	}
+	return e.value
+}
+
+// forTenant returns the config of the tenant of the context when it was
+// derived from this config.
+func (c *Config) forTenant(ctx context.Context) *Config {
+	if v, _ := ctx.Value(tenantKey{}).(*tenantValue); v != nil && v.config != nil && v.config.base == c {
+		return v.config.c
+	}
+	return c
+}
+
+// TenantFromHeader resolves the tenant from the request header.
+func TenantFromHeader(name string) TenantResolver {
+	return func(r *http.Request) string {
+		return strings.TrimSpace(r.Header.Get(name))
+	}
+}
+
+// TenantFromSubdomain resolves the tenant from the subdomain of the domain e.g.
+// acme for acme.example.com with the domain example.com.
+func TenantFromSubdomain(domain string) TenantResolver {
+	suffix := "." + strings.ToLower(strings.TrimPrefix(domain, "."))
+	return func(r *http.Request) string {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		sub, ok := strings.CutSuffix(strings.ToLower(host), suffix)
+		if !ok || strings.Contains(sub, ".") {
+			return ""
+		}
+		return sub
+	}
+}
+
+// TenantFromPath resolves the tenant from the path segment after the prefix
+// e.g. acme for /tenants/acme/users with the prefix /tenants/.
+func TenantFromPath(prefix string) TenantResolver {
+	return func(r *http.Request) string {
+		rest, ok := strings.CutPrefix(r.URL.Path, prefix)
+		if !ok {
+			return ""
+		}
+		tenant, _, _ := strings.Cut(rest, "/")
+		return tenant
+	}
+}
+
+// ClaimsVerifier verifies the bearer token and returns its claims, e.g. with a
+// JWT library and the keys of the issuer.
+type ClaimsVerifier func(r *http.Request, token string) (map[string]any, error)
+
+// TenantFromClaim resolves the tenant from the string claim of the bearer
+// token verified by the func. The tenant is resolved before the middleware
+// runs so the token must be verified here. It panics when the func is nil.
+func TenantFromClaim(claim string, verify ClaimsVerifier) TenantResolver {
+	if verify == nil {
+		panic("japi: TenantFromClaim requires a verifier so the tenant is not taken from an unverified token")
+	}
+
+	return func(r *http.Request) string {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok {
+			return ""
+		}
+		claims, err := verify(r, token)
+		if err != nil {
+			return ""
+		}
+		tenant, _ := claims[claim].(string)
+		return tenant
+	}
+}
@@ -0,0 +1,211 @@
+package japi
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/jarrettv/go-japi/problem"
+)
+
+// RequestTimeouts lets callers with their own latency budget set the deadline
+// of the request with a header e.g. X-Request-Timeout: 1.5s or the gRPC style
+// Grpc-Timeout: 1500m. A route timeout that is shorter still applies.
+type RequestTimeouts struct {
+	// Header is the request header with the timeout, defaults to X-Request-Timeout.
+	Header string
+	// Min is the shortest timeout, shorter timeouts are raised to it.
+	Min time.Duration
+	// Max is the longest timeout, longer timeouts are lowered to it. Zero disables the headers.
+	Max time.Duration
+}
+
+func (t RequestTimeouts) header() string {
+	if t.Header == "" {
+		return "X-Request-Timeout"
+	}
+	return t.Header
+}
+
+// timeout returns the timeout of the request headers bounded by Min and Max or
+// the route timeout when it is shorter. Zero is no timeout.
+func (t RequestTimeouts) timeout(r *http.Request, route time.Duration) (time.Duration, *problem.Problem) {
+	if t.Max <= 0 {
+		return route, nil
+	}
+
+	name, v := t.header(), r.Header.Get(t.header())
+	parse := parseTimeout
+	if v == "" {
+		name, v, parse = "Grpc-Timeout", r.Header.Get("Grpc-Timeout"), parseGRPCTimeout
+	}
+	if v == "" {
+		return route, nil
+	}
+
+	d, ok := parse(v)
+	if !ok {
+		errs := problem.Errors{}
+		errs.Add(name, "must be a duration")
+		return 0, problem.ValidationErrors(errs)
+	}
+	d = max(t.Min, min(d, t.Max))
+	if route > 0 && route < d {
+		return route, nil
+	}
+	return d, nil
+}
+
+// parseTimeout parses a duration e.g. 1.5s or 500ms or a number of seconds.
+func parseTimeout(v string) (time.Duration, bool) {
+	if secs, err := strconv.ParseFloat(v, 64); err == nil {
+		return time.Duration(secs * float64(time.Second)), secs > 0 && secs < 1e9
+	}
+	d, err := time.ParseDuration(v)
+	return d, err == nil && d > 0
+}
+
+// parseGRPCTimeout parses the up to 8 digits and the H, M, S, m, u or n unit of
+// a gRPC timeout.
+func parseGRPCTimeout(v string) (time.Duration, bool) {
+	if len(v) < 2 || len(v) > 9 {
+		return 0, false
+	}
+	n, err := strconv.ParseUint(v[:len(v)-1], 10, 64)
+	if err != nil || n == 0 {
+		return 0, false
+	}
+
+	i := strings.IndexByte("HMSmun", v[len(v)-1])
+	if i < 0 {
+		return 0, false
+	}
+	unit := []time.Duration{time.Hour, time.Minute, time.Second, time.Millisecond, time.Microsecond, time.Nanosecond}[i]
+	return time.Duration(n) * unit, true
+}
+
+// timeout wraps the handle with the deadline of the route or the request
+// headers. The response of routes with a timeout is buffered so writes after
+// the deadline are discarded. The deadline of the headers alone only sets the
+// deadline of the context so streamed and hijacked responses are not
+// buffered.
+func timeout(c *configRef, route time.Duration, next httprouter.Handle) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+		d, prob := c.Load().RequestTimeouts.timeout(r, route)
+		if prob != nil {
+			c.Load().serveProblem(w, r, prob)
+			return
+		}
+		if d <= 0 {
+			next(w, r, p)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), d)
+		defer cancel()
+
+		r = r.WithContext(ctx)
+		if route <= 0 {
+			next(w, r, p)
+			return
+		}
+
+		tw := &timeoutWriter{header: http.Header{}}
+		done := make(chan struct{})
+		panicked := make(chan any, 1)
+
+		go func() {
+			defer func() {
+				if v := recover(); v != nil {
+					panicked <- v
+				}
+			}()
+			next(tw, r, p)
+			close(done)
+		}()
+
+		select {
+		case v := <-panicked:
+			panic(v)
+		case <-done:
+			tw.mu.Lock()
+			defer tw.mu.Unlock()
+			headers := w.Header()
+			for k, v := range tw.header {
+				headers[k] = v
+			}
+			if tw.status != 0 {
+				w.WriteHeader(tw.status)
+			}
+			_, _ = w.Write(tw.buf.Bytes())
+		case <-ctx.Done():
+			tw.mu.Lock()
+			defer tw.mu.Unlock()
+			tw.timedOut = true
+			if !errors.Is(ctx.Err(), context.DeadlineExceeded) && !isShutdown(ctx) {
+				return // the client is gone
+			}
+			if isShutdown(ctx) {
+				c.Load().serveProblem(w, r, shutdownProblem())
+				return
+			}
+			c.Load().serveProblem(w, r, problem.Timeout())
+		}
+	}
+}
+
+type timeoutWriter struct {
+	mu       sync.Mutex
+	header   http.Header
+	buf      bytes.Buffer
+	status   int
+	timedOut bool
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+func (tw *timeoutWriter) Write(b []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if tw.status == 0 {
+		tw.status = http.StatusOK
+	}
+	return tw.buf.Write(b)
+}
+
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut || tw.status != 0 {
+		return
+	}
+	tw.status = code
+}
+
+// timedOut reports whether the response has timed out.
+func timedOut(w http.ResponseWriter) bool {
+	for {
+		switch tw := w.(type) {
+		case *timeoutWriter:
+			tw.mu.Lock()
+			defer tw.mu.Unlock()
+			return tw.timedOut
+		case interface{ Unwrap() http.ResponseWriter }:
+			w = tw.Unwrap()
+		default:
+			return false
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package japi
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/jarrettv/go-japi/problem"
+)
+
+// Tracer starts a span for each handled request.
+type Tracer interface {
+	// Start extracts any incoming trace context from the request and
+	// starts a span named after the route pattern.
+	Start(r *http.Request, route string) (context.Context, Span)
+}
+
+// Span records the phases of a handled request.
+type Span interface {
+	// Event records a named phase of the request.
+	Event(name string)
+	// Problem records the problem served for the request.
+	Problem(p *problem.Problem)
+	// End completes the span with the response status code.
+	End(status int)
+}
+
+type spanKey struct{}
+
+func spanFrom(ctx context.Context) Span {
+	if s, ok := ctx.Value(spanKey{}).(Span); ok {
+		return s
+	}
+	return nil
+}
+
+func spanEvent(ctx context.Context, name string) {
+	if s := spanFrom(ctx); s != nil {
+		s.Event(name)
+	}
+}
+
+// trace wraps the handle to start a span for the route.
+func trace(tracer Tracer, route string, next httprouter.Handle) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+		ctx, span := tracer.Start(r, route)
+		sw := newStatusWriter(w)
+		defer func() {
+			if v := recover(); v != nil {
+				span.End(http.StatusInternalServerError)
+				panic(v)
+			}
+			span.End(sw.Status())
+		}()
+
+		next(sw, r.WithContext(context.WithValue(ctx, spanKey{}, span)), p)
+	}
+}
@@ -0,0 +1,321 @@
+package japi
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/goccy/go-json"
+
+	"github.com/jarrettv/go-japi/decoder"
+	"github.com/jarrettv/go-japi/problem"
+)
+
+const validateTag = "validate"
+
+var optionalType = reflect.TypeOf((*decoder.Optional)(nil)).Elem()
+
+// rule returns the error message when the value is invalid.
+type rule func(v reflect.Value) string
+
+// fieldValidator validates a struct field with the rules of its tag.
+type fieldValidator struct {
+	index     int
+	name      string
+	required  bool
+	omitempty bool
+	rules     []rule
+	dive      *structValidator
+}
+
+// structValidator validates the fields of a struct type.
+type structValidator struct {
+	fields []fieldValidator
+}
+
+// newValidator returns the validator of the validate tags of the request type
+// or nil when it has none. It panics when a tag is invalid.
+func newValidator(t reflect.Type) *structValidator {
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct || !typeHasTag(t, validateTag) {
+		return nil
+	}
+	return compileValidator(t, map[reflect.Type]*structValidator{})
+}
+
+func compileValidator(t reflect.Type, seen map[reflect.Type]*structValidator) *structValidator {
+	if sv, ok := seen[t]; ok {
+		return sv
+	}
+
+	sv := &structValidator{}
+	seen[t] = sv
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+
+		fv := fieldValidator{index: i, name: fieldName(f)}
+		if tag, ok := f.Tag.Lookup(validateTag); ok {
+			parseRules(t, f, tag, &fv)
+		}
+
+		// validate the nested structs and struct slices with tags
+		et := f.Type
+		for et.Kind() == reflect.Pointer || et.Kind() == reflect.Slice || et.Kind() == reflect.Array {
+			et = et.Elem()
+		}
+		if et.Kind() == reflect.Struct && !et.Implements(optionalType) &&
+			!reflect.PointerTo(et).Implements(optionalType) && typeHasTag(et, validateTag) {
+			fv.dive = compileValidator(et, seen)
+		}
+
+		if fv.required || len(fv.rules) > 0 || fv.dive != nil {
+			sv.fields = append(sv.fields, fv)
+		}
+	}
+	return sv
+}
+
+func parseRules(t reflect.Type, f reflect.StructField, tag string, fv *fieldValidator) {
+	invalid := func(format string, args ...any) {
+		panic(fmt.Sprintf("japi: invalid validate tag on %s.%s: %s", t.Name(), f.Name, fmt.Sprintf(format, args...)))
+	}
+
+	for tag != "" {
+		var part string
+		if strings.HasPrefix(tag, "pattern=") {
+			part, tag = tag, "" // the pattern may have commas
+		} else {
+			part, tag, _ = strings.Cut(tag, ",")
+		}
+
+		name, param, _ := strings.Cut(part, "=")
+		switch name {
+		case "required":
+			fv.required = true
+		case "omitempty":
+			fv.omitempty = true
+		case "min", "max", "len":
+			n, err := strconv.ParseFloat(param, 64)
+			if err != nil {
+				invalid("%s=%s is not a number", name, param)
+			}
+			fv.rules = append(fv.rules, sizeRule(name, n, param))
+		case "oneof":
+			fv.rules = append(fv.rules, oneOfRule(strings.Fields(param)))
+		case "pattern":
+			re, err := regexp.Compile(param)
+			if err != nil {
+				invalid("%v", err)
+			}
+			fv.rules = append(fv.rules, func(v reflect.Value) string {
+				if v.Kind() == reflect.String && !re.MatchString(v.String()) {
+					return "must match the pattern " + param
+				}
+				return ""
+			})
+		default:
+			invalid("unknown rule %q", name)
+		}
+	}
+}
+
+// sizeRule compares numbers by value and strings, slices and maps by length.
+func sizeRule(name string, n float64, param string) rule {
+	check := func(size float64) bool {
+		switch name {
+		case "min":
+			return size >= n
+		case "max":
+			return size <= n
+		default:
+			return size == n
+		}
+	}
+
+	adjective := map[string]string{"min": "at least ", "max": "at most ", "len": ""}[name]
+	return func(v reflect.Value) string {
+		switch v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if !check(float64(v.Int())) {
+				return "must be " + adjective + param
+			}
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			if !check(float64(v.Uint())) {
+				return "must be " + adjective + param
+			}
+		case reflect.Float32, reflect.Float64:
+			if !check(v.Float()) {
+				return "must be " + adjective + param
+			}
+		case reflect.String:
+			if !check(float64(utf8.RuneCountInString(v.String()))) {
+				return "must be " + adjective + param + " characters"
+			}
+		case reflect.Slice, reflect.Array, reflect.Map:
+			if !check(float64(v.Len())) {
+				return "must have " + adjective + param + " items"
+			}
+		}
+		return ""
+	}
+}
+
+func oneOfRule(values []string) rule {
+	msg := "must be one of " + strings.Join(values, ", ")
+	return func(v reflect.Value) string {
+		s := fmt.Sprint(v.Interface())
+		for _, value := range values {
+			if s == value {
+				return ""
+			}
+		}
+		return msg
+	}
+}
+
+// validate adds the errors of the struct value to errs.
+func (sv *structValidator) validate(v reflect.Value, prefix string, errs problem.Errors) {
+	for _, fv := range sv.fields {
+		fv.validate(v.Field(fv.index), prefix+fv.name, errs)
+	}
+}
+
+func (fv *fieldValidator) validate(v reflect.Value, name string, errs problem.Errors) {
+	present := true
+	for present {
+		if v.Kind() == reflect.Pointer {
+			present = !v.IsNil()
+			if present {
+				v = v.Elem()
+			}
+		} else if v.CanAddr() && v.Addr().Type().Implements(optionalType) {
+			present = v.FieldByName("Present").Bool()
+			v = reflect.ValueOf(v.Addr().Interface().(decoder.Optional).OptionalValue()).Elem()
+		} else {
+			break
+		}
+	}
+
+	if !present || v.IsZero() {
+		if fv.required {
+			errs.Add(name, "is required")
+			return
+		}
+		if !present || fv.omitempty {
+			return
+		}
+	}
+
+	for _, rule := range fv.rules {
+		if msg := rule(v); msg != "" {
+			errs.Add(name, msg)
+		}
+	}
+
+	if fv.dive == nil {
+		return
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		fv.dive.validate(v, name+".", errs)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			item := v.Index(i)
+			for item.Kind() == reflect.Pointer && !item.IsNil() {
+				item = item.Elem()
+			}
+			if item.Kind() == reflect.Struct {
+				fv.dive.validate(item, fmt.Sprintf("%s[%d].", name, i), errs)
+			}
+		}
+	}
+}
+
+// fieldName returns the request name of the field from its tags.
+func fieldName(f reflect.StructField) string {
+	for _, tag := range []string{"json", queryTag, pathTag, headerTag} {
+		if name, _, _ := strings.Cut(f.Tag.Get(tag), ","); name != "" && name != "-" {
+			return name
+		}
+	}
+	return f.Name
+}
+
+// validateRequest validates the decoded request with the config validator or
+// the validate tags.
+func validateRequest(c *Config, sv *structValidator, v any) problem.Errors {
+	if c.Validator != nil {
+		return c.Validator(v)
+	}
+	if sv == nil {
+		return nil
+	}
+
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+
+	errs := problem.Errors{}
+	sv.validate(rv, "", errs)
+	return errs
+}
+
+// validateResponse checks the response with its validate tags and the route
+// response validator.
+func (h *handler[T, O]) validateResponse(res any) error {
+	if _, ok := res.(streamer); ok {
+		return nil // the items are not known before they are streamed
+	}
+
+	errs := problem.Errors{}
+	if h.resValidator != nil {
+		rv := reflect.ValueOf(res)
+		for rv.Kind() == reflect.Pointer && !rv.IsNil() {
+			rv = rv.Elem()
+		}
+		if rv.Kind() == reflect.Struct {
+			if !rv.CanAddr() {
+				cp := reflect.New(rv.Type()).Elem()
+				cp.Set(rv)
+				rv = cp
+			}
+			h.resValidator.validate(rv, "", errs)
+		}
+	}
+
+	if h.route != nil && h.route.responseValidator != nil {
+		data, err := json.Marshal(res)
+		if err != nil {
+			return err
+		}
+		if err := h.route.responseValidator.ValidateBody(bytes.NewReader(data), errs); err != nil {
+			return err
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	fields := make([]string, 0, len(errs))
+	for field, msgs := range errs {
+		fields = append(fields, field+" "+strings.Join(msgs, ", "))
+	}
+	sort.Strings(fields)
+	return fmt.Errorf("japi: invalid response: %s", strings.Join(fields, "; "))
+}
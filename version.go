@@ -0,0 +1,224 @@
+package japi
+
+import (
+	"context"
+	"mime"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/jarrettv/go-japi/problem"
+)
+
+var unsupportedVersion = problem.Register("unsupported-version", http.StatusBadRequest, "Unsupported API version").
+	Describe("The requested API version is not served")
+
+// Versioning configures how the API version of a request is negotiated.
+// Versions are compared as strings so use sortable versions e.g. 2023-10.
+type Versioning struct {
+	// Header is the request header with the version, defaults to X-API-Version.
+	Header string
+	// AcceptParam is the Accept media type param with the version, defaults to version.
+	AcceptParam string
+	// PathPrefix will also serve each version under the /<version> path prefix.
+	PathPrefix bool
+	// Default is the version when the request has none, the latest when empty.
+	Default string
+}
+
+func (v Versioning) header() string {
+	if v.Header == "" {
+		return "X-API-Version"
+	}
+	return v.Header
+}
+
+// requested returns the version requested by the header, Accept or default.
+func (v Versioning) requested(r *http.Request) string {
+	if s := r.Header.Get(v.header()); s != "" {
+		return s
+	}
+
+	param := v.AcceptParam
+	if param == "" {
+		param = "version"
+	}
+	for _, accept := range r.Header.Values("Accept") {
+		for _, part := range strings.Split(accept, ",") {
+			if _, params, err := mime.ParseMediaType(part); err == nil && params[param] != "" {
+				return params[param]
+			}
+		}
+	}
+
+	return v.Default
+}
+
+type versionKey struct{}
+
+// APIVersion returns the API version selected for the request.
+func APIVersion(ctx context.Context) string {
+	v, _ := ctx.Value(versionKey{}).(string)
+	return v
+}
+
+// versionedRoute dispatches the requests of a route to the handler of the
+// negotiated version.
+type versionedRoute struct {
+	config   *configRef
+	versions []string
+	handles  map[string]httprouter.Handle
+}
+
+func (vr *versionedRoute) add(version string, hh httprouter.Handle) {
+	if _, ok := vr.handles[version]; !ok {
+		vr.versions = append(vr.versions, version)
+		sort.Strings(vr.versions)
+	}
+	vr.handles[version] = hh
+}
+
+// match returns the latest version at or before the requested version.
+func (vr *versionedRoute) match(requested string) (string, bool) {
+	if requested == "" {
+		return vr.versions[len(vr.versions)-1], true
+	}
+
+	i := sort.Search(len(vr.versions), func(i int) bool { return vr.versions[i] > requested })
+	if i == 0 {
+		return "", false
+	}
+	return vr.versions[i-1], true
+}
+
+func (vr *versionedRoute) handle(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	c := vr.config.Load()
+	addVary(w.Header(), c.Versioning.header(), "Accept")
+	requested := c.Versioning.requested(r)
+	version, ok := vr.match(requested)
+	if !ok {
+		c.serveProblem(w, r, unsupportedVersion.Newf("Version %s is not supported", requested))
+		return
+	}
+
+	serveVersion(vr.config, version, vr.handles[version])(w, r, p)
+}
+
+// serveVersion wraps the handle to set the version in the context and response.
+func serveVersion(c *configRef, version string, next httprouter.Handle) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+		w.Header().Set(c.Load().Versioning.header(), version)
+		next(w, r.WithContext(context.WithValue(r.Context(), versionKey{}, version)), p)
+	}
+}
+
+// Version creates a sub-router to register the routes of the version. The
+// same route can be registered for many versions.
+func (r *API) Version(version string) Router {
+	return &versioned{r: r, version: version}
+}
+
+func (r *API) handleVersion(version string, rt *Route, hh httprouter.Handle) {
+	rt.Version = version
+	key := rt.Method + " " + rt.Path
+
+	vr, ok := r.versions[key]
+	if !ok {
+		if r.versions == nil {
+			r.versions = map[string]*versionedRoute{}
+		}
+		vr = &versionedRoute{config: r.config, handles: map[string]httprouter.Handle{}}
+		r.versions[key] = vr
+		r.register(rt.Method, rt.Path, vr.handle)
+	}
+	vr.add(version, hh)
+
+	if r.config.Load().Versioning.PathPrefix {
+		r.register(rt.Method, "/"+version+rt.Path, serveVersion(r.config, version, hh))
+	}
+
+	r.routes = append(r.routes, rt)
+}
+
+type versioned struct {
+	r       *API
+	prefix  string
+	version string
+	mw      []Middleware
+	opts    []RouteOption
+}
+
+func (v *versioned) Get(path string, handle http.Handler, opts ...RouteOption) {
+	v.Handle(http.MethodGet, path, handle, opts...)
+}
+
+func (v *versioned) Post(path string, handle http.Handler, opts ...RouteOption) {
+	v.Handle(http.MethodPost, path, handle, opts...)
+}
+
+func (v *versioned) Put(path string, handle http.Handler, opts ...RouteOption) {
+	v.Handle(http.MethodPut, path, handle, opts...)
+}
+
+func (v *versioned) Patch(path string, handle http.Handler, opts ...RouteOption) {
+	v.Handle(http.MethodPatch, path, handle, opts...)
+}
+
+func (v *versioned) Delete(path string, handle http.Handler, opts ...RouteOption) {
+	v.Handle(http.MethodDelete, path, handle, opts...)
+}
+
+func (v *versioned) Handle(method, path string, handle http.Handler, opts ...RouteOption) {
+	rt := newRoute(method, v.prefix+path, withGroupOptions(v.opts, opts))
+	hh := v.r.wrap(rt, handle)
+	if len(v.mw) > 0 {
+		hh = withMiddleware(v.mw, hh)
+	}
+	v.r.handleVersion(v.version, rt, hh)
+}
+
+func (v *versioned) HandleFunc(method, path string, handle http.HandlerFunc, opts ...RouteOption) {
+	v.Handle(method, path, handle, opts...)
+}
+
+func (v *versioned) Group(path string, opts ...RouteOption) Router {
+	return &versioned{r: v.r, prefix: v.prefix + path, version: v.version, mw: v.mw,
+		opts: withGroupOptions(v.opts, opts)}
+}
+
+func (v *versioned) Mount(path string, child *API) {
+	v.r.Mount(v.prefix+path, child)
+}
+
+func (v *versioned) Version(version string) Router {
+	return &versioned{r: v.r, prefix: v.prefix, version: version, opts: v.opts}
+}
+
+// Use will register middleware for the version routes registered afterwards.
+func (v *versioned) Use(mw ...Middleware) {
+	v.mw = append(v.mw, mw...)
+}
+
+// routeParamsKey carries the route params through the middleware of versions.
+type routeParamsKey struct{}
+
+// withMiddleware wraps the handle with the middleware. The chain is built once
+// and the params are passed through the request context.
+func withMiddleware(mw []Middleware, next httprouter.Handle) httprouter.Handle {
+	h := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p, _ := r.Context().Value(routeParamsKey{}).(httprouter.Params)
+		next(w, r, p)
+	}))
+	for i := len(mw) - 1; i >= 0; i-- {
+		h = mw[i](h)
+	}
+
+	return func(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+		if p != nil {
+			r = r.WithContext(context.WithValue(r.Context(), routeParamsKey{}, p))
+		}
+		h.ServeHTTP(w, r)
+	}
+}
@@ -0,0 +1,434 @@
+// Package webhook delivers signed webhook events to the subscribed endpoints
+// with retries. The signatures are verified by japi.WithSignature with the
+// japi.SignatureTimestamp of the TimestampHeader.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/goccy/go-json"
+)
+
+// The headers of a delivery.
+const (
+	IDHeader        = "Webhook-Id"
+	EventHeader     = "Webhook-Event"
+	SignatureHeader = "Webhook-Signature"
+	TimestampHeader = "Webhook-Timestamp"
+)
+
+// The statuses of a delivery.
+const (
+	Pending   = "pending"
+	Succeeded = "succeeded"
+	Failed    = "failed"
+)
+
+var (
+	// ErrUnknownEvent is returned when sending an event type that is not registered.
+	ErrUnknownEvent = errors.New("webhook: unknown event type")
+	// ErrQueueFull is returned when the deliveries cannot be queued.
+	ErrQueueFull = errors.New("webhook: queue is full")
+	// ErrClosed is returned when sending after the sender is closed.
+	ErrClosed = errors.New("webhook: sender is closed")
+)
+
+// Event is the body of a delivery.
+type Event struct {
+	ID      string    `json:"id"`
+	Type    string    `json:"type"`
+	Created time.Time `json:"created"`
+	Data    any       `json:"data"`
+}
+
+// Endpoint receives the events it subscribes to. No events subscribes to all.
+type Endpoint struct {
+	ID     string
+	URL    string
+	Secret []byte
+	Events []string
+}
+
+// Delivery is the status of an event sent to an endpoint.
+type Delivery struct {
+	ID         string    `json:"id"`
+	EventID    string    `json:"eventId"`
+	EventType  string    `json:"eventType"`
+	EndpointID string    `json:"endpointId"`
+	Status     string    `json:"status"`
+	Attempts   int       `json:"attempts"`
+	StatusCode int       `json:"statusCode,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	Created    time.Time `json:"created"`
+	Updated    time.Time `json:"updated"`
+}
+
+// Store stores the status of the deliveries.
+type Store interface {
+	Save(ctx context.Context, d Delivery) error
+	Get(ctx context.Context, id string) (Delivery, bool, error)
+}
+
+// Option configures the sender.
+type Option func(*Sender)
+
+// WithStore records the deliveries in the store. Deliveries are kept in memory
+// for a day by default.
+func WithStore(store Store) Option {
+	return func(s *Sender) {
+		s.store = store
+	}
+}
+
+// WithClient sends the deliveries with the client. The default client times
+// out after 10 seconds.
+func WithClient(client *http.Client) Option {
+	return func(s *Sender) {
+		s.client = client
+	}
+}
+
+// WithWorkers sets the deliveries sent at once and the size of the queue.
+func WithWorkers(workers, queue int) Option {
+	return func(s *Sender) {
+		s.workers, s.queueSize = workers, queue
+	}
+}
+
+// WithRetries sets the max attempts of a delivery and the backoff before each
+// retry. The default is 5 attempts doubling from a second, also used for a nil
+// backoff.
+func WithRetries(attempts int, backoff func(attempt int) time.Duration) Option {
+	return func(s *Sender) {
+		s.attempts = attempts
+		if backoff != nil {
+			s.backoff = backoff
+		}
+	}
+}
+
+// Sender queues and delivers the events.
+type Sender struct {
+	store     Store
+	client    *http.Client
+	workers   int
+	queueSize int
+	attempts  int
+	backoff   func(attempt int) time.Duration
+
+	mu        sync.RWMutex
+	events    map[string]bool
+	endpoints []Endpoint
+	closed    bool
+
+	queue chan *delivery
+	done  chan struct{}
+	wg    sync.WaitGroup
+	retry sync.WaitGroup
+}
+
+type delivery struct {
+	Delivery
+	endpoint Endpoint
+	payload  []byte
+}
+
+// New creates the sender and starts the workers.
+func New(opts ...Option) *Sender {
+	s := &Sender{
+		client:    &http.Client{Timeout: 10 * time.Second},
+		workers:   4,
+		queueSize: 1000,
+		attempts:  5,
+		backoff:   func(attempt int) time.Duration { return time.Second << (attempt - 1) },
+		events:    map[string]bool{},
+		done:      make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.store == nil {
+		s.store = NewMemoryStore(24 * time.Hour)
+	}
+
+	s.queue = make(chan *delivery, s.queueSize)
+	for i := 0; i < s.workers; i++ {
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			for d := range s.queue {
+				s.deliver(d)
+			}
+		}()
+	}
+	return s
+}
+
+// Register registers the event types that can be sent.
+func (s *Sender) Register(eventTypes ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, t := range eventTypes {
+		s.events[t] = true
+	}
+}
+
+// Subscribe adds the endpoint or replaces the endpoint with the same id.
+func (s *Sender) Subscribe(e Endpoint) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.endpoints {
+		if s.endpoints[i].ID == e.ID {
+			s.endpoints[i] = e
+			return
+		}
+	}
+	s.endpoints = append(s.endpoints, e)
+}
+
+// Unsubscribe removes the endpoint.
+func (s *Sender) Unsubscribe(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.endpoints {
+		if s.endpoints[i].ID == id {
+			s.endpoints = append(s.endpoints[:i], s.endpoints[i+1:]...)
+			return
+		}
+	}
+}
+
+// Send queues a delivery of the event to each subscribed endpoint and returns
+// the deliveries. When the queue is full the delivery that does not fit is
+// saved as failed and returned with ErrQueueFull.
+func (s *Sender) Send(ctx context.Context, eventType string, data any) ([]Delivery, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.closed {
+		return nil, ErrClosed
+	}
+	if !s.events[eventType] {
+		return nil, fmt.Errorf("%w %q", ErrUnknownEvent, eventType)
+	}
+
+	now := time.Now()
+	event := Event{ID: newID(), Type: eventType, Created: now, Data: data}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+
+	var deliveries []Delivery
+	for _, e := range s.endpoints {
+		if !e.subscribes(eventType) {
+			continue
+		}
+
+		d := &delivery{endpoint: e, payload: payload, Delivery: Delivery{
+			ID: newID(), EventID: event.ID, EventType: eventType, EndpointID: e.ID,
+			Status: Pending, Created: now, Updated: now,
+		}}
+		if err := s.store.Save(ctx, d.Delivery); err != nil {
+			return deliveries, err
+		}
+
+		queued := d.Delivery
+		select {
+		case s.queue <- d:
+			deliveries = append(deliveries, queued)
+		default:
+			queued.Status, queued.Error, queued.Updated = Failed, ErrQueueFull.Error(), time.Now()
+			if err := s.store.Save(ctx, queued); err != nil {
+				return deliveries, err
+			}
+			return append(deliveries, queued), ErrQueueFull
+		}
+	}
+	return deliveries, nil
+}
+
+// Get returns the status of the delivery.
+func (s *Sender) Get(ctx context.Context, id string) (Delivery, bool, error) {
+	return s.store.Get(ctx, id)
+}
+
+// Close stops accepting events, drops the pending retries and waits for the
+// queued deliveries to finish.
+func (s *Sender) Close() {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return
+	}
+	s.closed = true
+	close(s.done)
+	s.mu.Unlock()
+
+	s.retry.Wait()
+	close(s.queue)
+	s.wg.Wait()
+}
+
+func (s *Sender) deliver(d *delivery) {
+	ctx := context.Background()
+	d.Attempts++
+	d.StatusCode, d.Error = 0, ""
+
+	res, err := s.post(ctx, d)
+	if err != nil {
+		d.Error = err.Error()
+	} else {
+		d.StatusCode = res.StatusCode
+		_, _ = io.Copy(io.Discard, res.Body)
+		_ = res.Body.Close()
+	}
+
+	d.Updated = time.Now()
+	if err == nil && d.StatusCode < 300 {
+		d.Status = Succeeded
+	} else if d.Attempts >= s.attempts {
+		d.Status = Failed
+	}
+	_ = s.store.Save(ctx, d.Delivery)
+
+	if d.Status == Pending {
+		s.retryLater(d)
+	}
+}
+
+// retryLater queues the delivery again after the backoff unless the sender is
+// closed first.
+func (s *Sender) retryLater(d *delivery) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return
+	}
+
+	s.retry.Add(1)
+	go func() {
+		defer s.retry.Done()
+		timer := time.NewTimer(s.backoff(d.Attempts))
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+			select {
+			case s.queue <- d:
+			case <-s.done:
+			}
+		case <-s.done:
+		}
+	}()
+}
+
+func (s *Sender) post(ctx context.Context, d *delivery) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.endpoint.URL, bytes.NewReader(d.payload))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(IDHeader, d.EventID)
+	req.Header.Set(EventHeader, d.EventType)
+	if d.endpoint.Secret != nil {
+		now := time.Now()
+		req.Header.Set(TimestampHeader, strconv.FormatInt(now.Unix(), 10))
+		req.Header.Set(SignatureHeader, Sign(d.endpoint.Secret, now, d.payload))
+	}
+	return s.client.Do(req)
+}
+
+func (e *Endpoint) subscribes(eventType string) bool {
+	if len(e.Events) == 0 {
+		return true
+	}
+	for _, t := range e.Events {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// Sign returns the sha256= hex HMAC signature of the unix seconds of the
+// timestamp, a dot and the payload. Each attempt is signed with the time it is
+// sent in the TimestampHeader.
+func Sign(secret []byte, timestamp time.Time, payload []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(strconv.FormatInt(timestamp.Unix(), 10) + "."))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func newID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// MemoryStore is an in memory Store for a single instance.
+type MemoryStore struct {
+	ttl        time.Duration
+	mu         sync.Mutex
+	deliveries map[string]Delivery
+	expires    []expiringDelivery
+}
+
+// expiringDelivery is when a finished delivery expires. The deliveries finish
+// in order so the expiring deliveries are ordered by their expiry.
+type expiringDelivery struct {
+	id string
+	at time.Time
+}
+
+// NewMemoryStore creates an in memory Store that keeps the finished deliveries
+// for the ttl.
+func NewMemoryStore(ttl time.Duration) *MemoryStore {
+	return &MemoryStore{ttl: ttl, deliveries: map[string]Delivery{}}
+}
+
+// Save saves the delivery and removes the expired deliveries.
+func (s *MemoryStore) Save(_ context.Context, d Delivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for len(s.expires) > 0 && now.After(s.expires[0].at) {
+		id := s.expires[0].id
+		s.expires = s.expires[1:]
+		if old, ok := s.deliveries[id]; ok && old.Status != Pending && now.Sub(old.Updated) > s.ttl {
+			delete(s.deliveries, id)
+		}
+	}
+
+	s.deliveries[d.ID] = d
+	if d.Status != Pending {
+		s.expires = append(s.expires, expiringDelivery{id: d.ID, at: d.Updated.Add(s.ttl)})
+	}
+	return nil
+}
+
+// Get returns the delivery.
+func (s *MemoryStore) Get(_ context.Context, id string) (Delivery, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	d, ok := s.deliveries[id]
+	return d, ok, nil
+}
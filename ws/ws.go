@@ -0,0 +1,126 @@
+// Package ws upgrades japi requests to websockets that read and write json
+// messages.
+//
+//	r.Get("/echo", ws.H(Echo))
+package ws
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/goccy/go-json"
+	"github.com/gorilla/websocket"
+
+	"github.com/jarrettv/go-japi"
+	"github.com/jarrettv/go-japi/problem"
+)
+
+// Handle is the type for your websocket handlers. Return a problem to close
+// the connection with the problem details.
+type Handle[I any, O any] func(ctx context.Context, conn *Conn[I, O]) error
+
+// Conn is a websocket connection that reads I and writes O json messages.
+type Conn[I any, O any] struct {
+	ws  *websocket.Conn
+	req *http.Request
+}
+
+// Request returns the upgraded http request.
+func (c *Conn[I, O]) Request() *http.Request {
+	return c.req
+}
+
+// Read reads the next json message. The error is a websocket.CloseError
+// when the client closes the connection.
+func (c *Conn[I, O]) Read() (I, error) {
+	var msg I
+
+	_, data, err := c.ws.ReadMessage()
+	if err != nil {
+		return msg, err
+	}
+
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return msg, problem.BadRequest(err)
+	}
+
+	return msg, nil
+}
+
+// Write writes the json message.
+func (c *Conn[I, O]) Write(msg O) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	return c.ws.WriteMessage(websocket.TextMessage, data)
+}
+
+// H wraps your websocket handler to upgrade the request.
+func H[I any, O any](handle Handle[I, O]) http.Handler {
+	return With(websocket.Upgrader{}, handle)
+}
+
+// With wraps your websocket handler to upgrade the request with the upgrader
+// e.g. to allow cross origin requests.
+func With[I any, O any](upgrader websocket.Upgrader, handle Handle[I, O]) http.Handler {
+	return &handler[I, O]{handler: handle, upgrader: upgrader}
+}
+
+type handler[I any, O any] struct {
+	problems japi.Problems
+	handler  Handle[I, O]
+	upgrader websocket.Upgrader
+}
+
+// SetProblems implements japi.ProblemsSetter.
+func (h *handler[I, O]) SetProblems(p japi.Problems) {
+	h.problems = p
+}
+
+func (h *handler[I, O]) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	upgrader := h.upgrader
+	upgrader.Error = func(w http.ResponseWriter, r *http.Request, status int, reason error) {
+		p := problem.Status(status)
+		p.Detail = reason.Error()
+		h.problems.Serve(w, r, p)
+	}
+
+	ws, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return // upgrader has served the problem
+	}
+	defer ws.Close()
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	err = h.handler(ctx, &Conn[I, O]{ws: ws, req: r})
+
+	var ce *websocket.CloseError
+	if errors.As(err, &ce) {
+		return // client closed the connection
+	}
+
+	if err == nil {
+		_ = ws.WriteMessage(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+		return
+	}
+
+	p := h.problems.Prepare(r, err)
+
+	// Send the problem details then close with 4000 + the http status.
+	if data, err := json.Marshal(p); err == nil {
+		_ = ws.WriteMessage(websocket.TextMessage, data)
+	}
+
+	reason := p.Title
+	if len(reason) > 123 {
+		reason = reason[:123]
+	}
+	_ = ws.WriteMessage(websocket.CloseMessage,
+		websocket.FormatCloseMessage(4000+p.Status, reason))
+}